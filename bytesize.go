@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes, parsed from and formatted to human readable
+// sizes such as `64MiB` or `1.5GB`.
+type ByteSize uint64
+
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+	EB          = 1000 * PB
+
+	KiB ByteSize = 1 << 10
+	MiB ByteSize = 1 << 20
+	GiB ByteSize = 1 << 30
+	TiB ByteSize = 1 << 40
+	PiB ByteSize = 1 << 50
+	EiB ByteSize = 1 << 60
+)
+
+var (
+	ErrInvalidByteSize    = errors.New("invalid byte size")
+	ErrFractionalByteSize = errors.New("byte size is not a whole number of bytes")
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"kb":  KB,
+	"mb":  MB,
+	"gb":  GB,
+	"tb":  TB,
+	"pb":  PB,
+	"eb":  EB,
+	"kib": KiB,
+	"mib": MiB,
+	"gib": GiB,
+	"tib": TiB,
+	"pib": PiB,
+	"eib": EiB,
+}
+
+// ParseByteSize parses a human readable size. The number may have a fraction
+// and is optionally followed by a SI (`kB`, `MB`, ... `EB`) or IEC (`KiB`,
+// `MiB`, ... `EiB`) unit. Units are case-insensitive. Number without unit or
+// with `B` unit are bytes. `ErrFractionalByteSize` is returned if the size is
+// not a whole number of bytes, such as `1.5B` or `0.0001kB`.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	idx := strings.IndexFunc(
+		s, func(r rune) bool { return !('0' <= r && r <= '9' || '.' == r) },
+	)
+	if idx < 0 {
+		idx = len(s)
+	}
+	num, unit := s[:idx], strings.ToLower(strings.TrimSpace(s[idx:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if "" == num || !ok {
+		return 0, ErrInvalidByteSize
+	}
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if n > math.MaxUint64/uint64(multiplier) {
+			return 0, strconv.ErrRange
+		}
+		return ByteSize(n) * multiplier, nil
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return 0, ErrInvalidByteSize
+	}
+	r.Mul(r, new(big.Rat).SetUint64(uint64(multiplier)))
+	if !r.IsInt() {
+		return 0, ErrFractionalByteSize
+	}
+	if !r.Num().IsUint64() {
+		return 0, strconv.ErrRange
+	}
+	return ByteSize(r.Num().Uint64()), nil
+}
+
+// String formats the size using the IEC or SI unit that represents the size
+// exactly with the smallest number. IEC units are preferred on tie.
+func (b ByteSize) String() string {
+	if 0 == b {
+		return "0B"
+	}
+	units := []struct {
+		name string
+		size ByteSize
+	}{
+		{"EiB", EiB}, {"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB},
+		{"KiB", KiB}, {"EB", EB}, {"PB", PB}, {"TB", TB}, {"GB", GB},
+		{"MB", MB}, {"kB", KB},
+	}
+	num, name := b, "B"
+	for _, unit := range units {
+		if 0 == b%unit.size && b/unit.size < num {
+			num, name = b/unit.size, unit.name
+		}
+	}
+	return strconv.FormatUint(uint64(num), 10) + name
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(data []byte) error {
+	size, err := ParseByteSize(string(data))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    ByteSize
+		wantErr error
+	}{
+		{"bytes without unit", "123", 123, nil},
+		{"bytes", "123B", 123, nil},
+		{"SI kilo", "2kB", 2000, nil},
+		{"SI mega", "64MB", 64 * MB, nil},
+		{"SI exa", "1EB", EB, nil},
+		{"IEC kibi", "2KiB", 2048, nil},
+		{"IEC mebi", "64MiB", 64 << 20, nil},
+		{"IEC exbi", "15EiB", 15 * EiB, nil},
+		{"case insensitive", "64mib", 64 * MiB, nil},
+		{"spaces", " 1 GiB ", GiB, nil},
+		{"fraction", "1.5GB", 1500 * MB, nil},
+		{"fraction IEC", "0.5KiB", 512, nil},
+		{"fraction of decimal unit", "1.1kB", 1100, nil},
+		{"fractional bytes", "1.9", 0, ErrFractionalByteSize},
+		{"exact fraction of unit", "0.001kB", 1, nil},
+		{"fractional bytes of unit", "0.0001kB", 0, ErrFractionalByteSize},
+		{"missing number", "MiB", 0, ErrInvalidByteSize},
+		{"unknown unit", "1XB", 0, ErrInvalidByteSize},
+		{"negative", "-1", 0, ErrInvalidByteSize},
+		{"invalid fraction", "1.2.3MB", 0, ErrInvalidByteSize},
+		{"overflow", "16EiB", 0, strconv.ErrRange},
+		{"overflow fraction", "16.5EiB", 0, strconv.ErrRange},
+		{"overflow number", "99999999999999999999", 0, strconv.ErrRange},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := ParseByteSize(tt.input)
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func Test_ByteSize_String(t *testing.T) {
+	require.Equal(t, "0B", ByteSize(0).String())
+	require.Equal(t, "123B", ByteSize(123).String())
+	require.Equal(t, "64MiB", (64 * MiB).String())
+	require.Equal(t, "1500kB", (1500 * KB).String())
+	require.Equal(t, "3EiB", (3 * EiB).String())
+	require.Equal(t, "2GB", (2 * GB).String())
+}
+
+func Test_ByteSize_text(t *testing.T) {
+	var b ByteSize
+	require.Nil(t, b.UnmarshalText([]byte("2KiB")))
+	require.Equal(t, 2*KiB, b)
+	text, err := b.MarshalText()
+	require.Nil(t, err)
+	require.Equal(t, "2KiB", string(text))
+	require.ErrorIs(t, b.UnmarshalText([]byte("abc")), ErrInvalidByteSize)
+	require.Equal(t, 2*KiB, b)
+}
@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Names of configuration layers created by `NewLayeredEnv()`.
+const (
+	LayerFlags    = "flags"
+	LayerEnv      = "env"
+	LayerFile     = "file"
+	LayerDefaults = "defaults"
+)
+
+var (
+	ErrInvalidJsonConfig  = errors.New("JSON config must be an object")
+	ErrJsonConfigConflict = errors.New("JSON config keys set the same variable")
+	ErrJsonConfigTrailing = errors.New("unexpected data after JSON config")
+)
+
+var (
+	_ EnvSource = FlagEnv{}
+	_ EnvSource = LayeredEnv{}
+)
+
+// FlagEnv reads variables from flags explicitly set on the command line.
+// Variable names are mapped to flag names by `EnvFlagName()`.
+type FlagEnv struct {
+	Set *flag.FlagSet
+}
+
+func (f FlagEnv) LookupEnv(key string) (string, bool) {
+	name := EnvFlagName(key)
+	found := false
+	f.Set.Visit(func(fl *flag.Flag) { found = found || name == fl.Name })
+	if !found {
+		return "", false
+	}
+	return f.Set.Lookup(name).Value.String(), true
+}
+
+func (f FlagEnv) Keys() []string {
+	var keys []string
+	f.Set.Visit(
+		func(fl *flag.Flag) { keys = append(keys, FlagEnvName(fl.Name)) },
+	)
+	slices.Sort(keys)
+	return keys
+}
+
+// EnvFlagName returns the flag name of the variable, in lower kebab-case,
+// such as `db-host` for `DB_HOST`.
+func EnvFlagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// FlagEnvName returns the variable name of the flag, in upper snake-case,
+// such as `DB_HOST` for `db-host`. Words of camelCase names are separated too,
+// such as `DB_HOST_NAME` for `dbHostName` and `URL_PATH` for `URLPath`.
+func FlagEnvName(name string) string {
+	rs := []rune(name)
+	var sb strings.Builder
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(rs[i-1]) ||
+			unicode.IsDigit(rs[i-1]) || unicode.IsUpper(rs[i-1]) &&
+			i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+			sb.WriteRune('_')
+		}
+		if '-' == r {
+			r = '_'
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
+
+// EnvLayer is a named source of `LayeredEnv`.
+type EnvLayer struct {
+	Name   string
+	Source EnvSource
+}
+
+// LayeredEnv reads variables from a list of layers, the first layer having the
+// variable wins. It reports which layer each value comes from.
+type LayeredEnv []EnvLayer
+
+// NewLayeredEnv returns layers of flags explicitly set on `fs`, the process
+// environment, the config file and defaults, in order of precedence. `nil`
+// arguments are skipped.
+func NewLayeredEnv(fs *flag.FlagSet, file, defaults EnvSource) LayeredEnv {
+	var layers LayeredEnv
+	if nil != fs {
+		layers = append(layers, EnvLayer{LayerFlags, FlagEnv{fs}})
+	}
+	layers = append(layers, EnvLayer{LayerEnv, OsEnv{}})
+	if nil != file {
+		layers = append(layers, EnvLayer{LayerFile, file})
+	}
+	if nil != defaults {
+		layers = append(layers, EnvLayer{LayerDefaults, defaults})
+	}
+	return layers
+}
+
+func (l LayeredEnv) LookupEnv(key string) (string, bool) {
+	for _, layer := range l {
+		if val, found := layer.Source.LookupEnv(key); found {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+func (l LayeredEnv) Keys() []string {
+	var keys []string
+	for _, layer := range l {
+		keys = append(keys, layer.Source.Keys()...)
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// Origin returns the name of the layer providing the variable. The returned
+// boolean is `false` if no layer has the variable.
+func (l LayeredEnv) Origin(key string) (string, bool) {
+	for _, layer := range l {
+		if _, found := layer.Source.LookupEnv(key); found {
+			return layer.Name, true
+		}
+	}
+	return "", false
+}
+
+// Origins returns the name of the layer providing each variable.
+func (l LayeredEnv) Origins() map[string]string {
+	origins := map[string]string{}
+	for i := len(l) - 1; i >= 0; i-- {
+		for _, key := range l[i].Source.Keys() {
+			origins[key] = l[i].Name
+		}
+	}
+	return origins
+}
+
+// Origin returns the name of the layer providing the variable named by the
+// key, if `Source` is a `LayeredEnv`. The returned boolean is `false` if the
+// variable is not found, or the source has no layers.
+func (e *Env) Origin(key string) (string, bool) {
+	layers, ok := e.Source.(LayeredEnv)
+	if !ok {
+		return "", false
+	}
+	return layers.Origin(e.Prefix + key)
+}
+
+// NewConfigEnv returns an `Env` reading layers of flags explicitly set on
+// `fs`, the process environment, the JSON config file and defaults, in order
+// of precedence. `fs` may be `nil`, and `file` may be empty.
+func NewConfigEnv(fs *flag.FlagSet, file string, defaults MapEnv) (
+	*Env, error,
+) {
+	var fileEnv EnvSource
+	if "" != file {
+		m, err := ReadJsonConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		fileEnv = m
+	}
+	var defaultEnv EnvSource
+	if nil != defaults {
+		defaultEnv = defaults
+	}
+	return NewEnv(NewLayeredEnv(fs, fileEnv, defaultEnv)), nil
+}
+
+// ParseJsonConfig parses a JSON object into variables, decoded by `Jsoniter`.
+// Nested objects are flattened, joining keys by `_`. Keys are converted to
+// upper snake-case by `FlagEnvName()`, so both `{"db": {"host-name": "x"}}`
+// and `{"db": {"hostName": "x"}}` set `DB_HOST_NAME`.
+// Arrays of scalars are formatted by `FormatCsv()`, other arrays are kept as
+// JSON. `null` values are ignored. `ErrJsonConfigConflict` is returned if
+// different keys map to the same variable, such as `{"db_host": 1, "db":
+// {"host": 2}}`, and `ErrJsonConfigTrailing` if data follow the object.
+func ParseJsonConfig(r io.Reader) (MapEnv, error) {
+	var root any
+	dec := Jsoniter.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+	rest, err := io.ReadAll(io.MultiReader(dec.Buffered(), r))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return nil, ErrJsonConfigTrailing
+	}
+	obj, ok := root.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidJsonConfig
+	}
+	env := MapEnv{}
+	if err = flattenJsonConfig(env, map[string]string{}, "", "", obj); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// ReadJsonConfig reads variables from the JSON config file, see
+// `ParseJsonConfig()`.
+func ReadJsonConfig(file string) (MapEnv, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	env, err := ParseJsonConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return env, nil
+}
+
+// flattenJsonConfig sets variables of the object, whose keys are prefixed by
+// `prefix`, and whose JSON path is `path`. `paths` holds the JSON paths of
+// variables already set, to detect conflicts.
+func flattenJsonConfig(
+	env MapEnv, paths map[string]string, prefix, path string,
+	obj map[string]any,
+) error {
+	for k, v := range obj {
+		key, p := prefix+FlagEnvName(k), path+k
+		if nil == v {
+			continue
+		}
+		if val, ok := v.(map[string]any); ok {
+			err := flattenJsonConfig(env, paths, key+"_", p+".", val)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if other, ok := paths[key]; ok {
+			first, second := min(other, p), max(other, p)
+			return fmt.Errorf(
+				"%w: %s and %s set %s", ErrJsonConfigConflict, first, second,
+				key,
+			)
+		}
+		paths[key] = p
+		switch val := v.(type) {
+		case []any:
+			s, err := formatJsonConfigArray(val)
+			if err != nil {
+				return err
+			}
+			env[key] = s
+		default:
+			env[key] = formatJsonConfigScalar(val)
+		}
+	}
+	return nil
+}
+
+func formatJsonConfigArray(a []any) (string, error) {
+	items := make([]string, len(a))
+	for i, v := range a {
+		switch v.(type) {
+		case nil, map[string]any, []any:
+			return Jsoniter.MarshalToString(a)
+		}
+		items[i] = formatJsonConfigScalar(v)
+	}
+	return FormatCsv(items, CsvOptions{}), nil
+}
+
+func formatJsonConfigScalar(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return val.String()
+	}
+	s, _ := Jsoniter.MarshalToString(v)
+	return s
+}
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvFlagName(t *testing.T) {
+	require.Equal(t, "db-host", EnvFlagName("DB_HOST"))
+	require.Equal(t, "DB_HOST", FlagEnvName("db-host"))
+	require.Equal(t, "port", EnvFlagName("PORT"))
+	require.Equal(t, "DB_HOST", FlagEnvName("DB_HOST"))
+	require.Equal(t, "DB_HOST_NAME", FlagEnvName("dbHostName"))
+	require.Equal(t, "URL_PATH", FlagEnvName("URLPath"))
+	require.Equal(t, "IPV4_ADDR", FlagEnvName("ipv4Addr"))
+}
+
+func Test_FlagEnv(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db-host", "localhost", "")
+	fs.Int("port", 80, "")
+	fs.Bool("debug", false, "")
+	require.Nil(t, fs.Parse([]string{"-port", "8080", "-debug"}))
+	src := FlagEnv{fs}
+	val, found := src.LookupEnv("PORT")
+	require.True(t, found)
+	require.Equal(t, "8080", val)
+	val, found = src.LookupEnv("DEBUG")
+	require.True(t, found)
+	require.Equal(t, "true", val)
+	_, found = src.LookupEnv("DB_HOST")
+	require.False(t, found)
+	_, found = src.LookupEnv("MISSING")
+	require.False(t, found)
+	require.Equal(t, []string{"DEBUG", "PORT"}, src.Keys())
+}
+
+func Test_LayeredEnv(t *testing.T) {
+	layers := LayeredEnv{
+		{"a", MapEnv{"X": "a", "Y": "a"}},
+		{"b", MapEnv{"X": "b", "Z": "b"}},
+	}
+	val, found := layers.LookupEnv("X")
+	require.True(t, found)
+	require.Equal(t, "a", val)
+	val, found = layers.LookupEnv("Z")
+	require.True(t, found)
+	require.Equal(t, "b", val)
+	_, found = layers.LookupEnv("W")
+	require.False(t, found)
+	require.Equal(t, []string{"X", "Y", "Z"}, layers.Keys())
+	origin, found := layers.Origin("Z")
+	require.True(t, found)
+	require.Equal(t, "b", origin)
+	_, found = layers.Origin("W")
+	require.False(t, found)
+	require.Equal(
+		t, map[string]string{"X": "a", "Y": "a", "Z": "b"}, layers.Origins(),
+	)
+}
+
+func Test_NewLayeredEnv(t *testing.T) {
+	layers := NewLayeredEnv(nil, nil, nil)
+	require.Equal(t, LayeredEnv{{LayerEnv, OsEnv{}}}, layers)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	layers = NewLayeredEnv(fs, MapEnv{}, MapEnv{})
+	require.Equal(
+		t, []string{LayerFlags, LayerEnv, LayerFile, LayerDefaults},
+		Pluck(layers, func(l EnvLayer) string { return l.Name }),
+	)
+}
+
+func Test_NewConfigEnv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	require.Nil(
+		t, os.WriteFile(
+			file, []byte(`{
+				"test_config": {
+					"host": "file-host",
+					"port": 8000,
+					"name": "file-name",
+					"tags": ["a", "b c"]
+				}
+			}`), 0600,
+		),
+	)
+	t.Setenv("TEST_CONFIG_NAME", "env-name")
+	t.Setenv("TEST_CONFIG_PORT", "9000")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("test-config-port", 0, "")
+	require.Nil(t, fs.Parse([]string{"-test-config-port=7000"}))
+	env, err := NewConfigEnv(
+		fs, file, MapEnv{
+			"TEST_CONFIG_HOST":  "default-host",
+			"TEST_CONFIG_DEBUG": "true",
+		},
+	)
+	require.Nil(t, err)
+	cfg := env.Scope("TEST_CONFIG_")
+	tests := []struct {
+		key    string
+		value  string
+		origin string
+	}{
+		{"PORT", "7000", LayerFlags},
+		{"NAME", "env-name", LayerEnv},
+		{"HOST", "file-host", LayerFile},
+		{"DEBUG", "true", LayerDefaults},
+		{"TAGS", "a,b c", LayerFile},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.key, func(t *testing.T) {
+				require.Equal(t, tt.value, cfg.MustGetEnv(tt.key))
+				origin, found := cfg.Origin(tt.key)
+				require.True(t, found)
+				require.Equal(t, tt.origin, origin)
+			},
+		)
+	}
+	port, err := cfg.GetEnvUint16("PORT", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint16(7000), port)
+	_, found := cfg.Origin("MISSING")
+	require.False(t, found)
+	_, found = DefaultEnv.Origin("TEST_CONFIG_PORT")
+	require.False(t, found)
+	origins := env.Source.(LayeredEnv).Origins()
+	require.Equal(t, LayerFlags, origins["TEST_CONFIG_PORT"])
+}
+
+func Test_NewConfigEnv_errors(t *testing.T) {
+	_, err := NewConfigEnv(nil, filepath.Join(t.TempDir(), "none"), nil)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	file := filepath.Join(t.TempDir(), "config.json")
+	require.Nil(t, os.WriteFile(file, []byte(`[]`), 0600))
+	_, err = NewConfigEnv(nil, file, nil)
+	require.ErrorIs(t, err, ErrInvalidJsonConfig)
+	require.ErrorContains(t, err, file)
+	env, err := NewConfigEnv(nil, "", nil)
+	require.Nil(t, err)
+	require.Len(t, env.Source, 1)
+}
+
+func Test_ParseJsonConfig(t *testing.T) {
+	env, err := ParseJsonConfig(
+		strings.NewReader(
+			`{
+				"ratio": 1.50,
+				"big": 12345678901234567890,
+				"debug": true,
+				"off": false,
+				"db": {"host-name": "h", "replica": {"port": 1}},
+				"cache": {"maxSize": 2},
+				"list": [1, "x,y", true],
+				"rules": [{"name": "a"}],
+				"nested": [[1]],
+				"none": null
+			}`,
+		),
+	)
+	require.Nil(t, err)
+	require.Equal(
+		t, MapEnv{
+			"RATIO":           "1.50",
+			"BIG":             "12345678901234567890",
+			"DEBUG":           "true",
+			"OFF":             "false",
+			"DB_HOST_NAME":    "h",
+			"DB_REPLICA_PORT": "1",
+			"CACHE_MAX_SIZE":  "2",
+			"LIST":            `1,"x,y",true`,
+			"RULES":           `[{"name":"a"}]`,
+			"NESTED":          `[[1]]`,
+		}, env,
+	)
+	_, err = ParseJsonConfig(strings.NewReader(`{`))
+	require.NotNil(t, err)
+	_, err = ParseJsonConfig(strings.NewReader(`"a"`))
+	require.ErrorIs(t, err, ErrInvalidJsonConfig)
+}
+
+func Test_ParseJsonConfig_rejects_conflicting_keys(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		_, err := ParseJsonConfig(
+			strings.NewReader(`{"db_host": 1, "db": {"host": 2}, "x": null}`),
+		)
+		require.ErrorIs(t, err, ErrJsonConfigConflict)
+		require.EqualError(
+			t, err, "JSON config keys set the same variable: db.host and "+
+				"db_host set DB_HOST",
+		)
+	}
+	env, err := ParseJsonConfig(
+		strings.NewReader(`{"db_host": 1, "db": {"host": null}}`),
+	)
+	require.Nil(t, err)
+	require.Equal(t, MapEnv{"DB_HOST": "1"}, env)
+}
+
+func Test_ParseJsonConfig_rejects_trailing_data(t *testing.T) {
+	env, err := ParseJsonConfig(strings.NewReader("{\"a\": 1}\n\t "))
+	require.Nil(t, err)
+	require.Equal(t, MapEnv{"A": "1"}, env)
+	_, err = ParseJsonConfig(strings.NewReader(`{"a": 1} {"b": 2}`))
+	require.ErrorIs(t, err, ErrJsonConfigTrailing)
+	_, err = ParseJsonConfig(strings.NewReader(`{"a": 1} x`))
+	require.ErrorIs(t, err, ErrJsonConfigTrailing)
+}
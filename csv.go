@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrCsvUnterminatedQuote = errors.New("unterminated quoted item")
+	ErrCsvUnexpectedQuote   = errors.New("unexpected character after quoted item")
+)
+
+// CsvOptions controls how `ParseCsv()` splits a string into items. The zero
+// value splits by `,`, trims items and removes empty ones.
+type CsvOptions struct {
+	// Separator between items, defaults to `,`
+	Separator rune
+	// Character making the next character literal, such as `\`. Escaping is
+	// disabled if it is zero.
+	Escape rune
+	// Keeps leading and trailing white spaces of unquoted items
+	KeepSpaces bool
+	// Keeps empty items
+	KeepEmpty bool
+}
+
+// ParseCsv splits the string into items as described in RFC 4180. Items may
+// be enclosed in double quotes, to contain separators, quotes (written as
+// `""`) or line breaks. Contents of quoted items are kept as-is, only
+// spaces around the quotes are ignored.
+func ParseCsv(s string, opts CsvOptions) ([]string, error) {
+	sep := opts.Separator
+	if 0 == sep {
+		sep = ','
+	}
+	rs := []rune(s)
+	items := []string{}
+	var sb strings.Builder
+	for i := 0; ; i++ {
+		sb.Reset()
+		start := i
+		for i < len(rs) && sep != rs[i] && unicode.IsSpace(rs[i]) {
+			i++
+		}
+		quoted := i < len(rs) && '"' == rs[i]
+		var err error
+		if quoted {
+			i, err = parseCsvQuoted(rs, i+1, sep, opts.Escape, &sb)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", len(items), err)
+			}
+		} else {
+			i = parseCsvUnquoted(rs, start, sep, opts.Escape, &sb)
+		}
+		item := sb.String()
+		if !quoted && !opts.KeepSpaces {
+			item = strings.TrimSpace(item)
+		}
+		if opts.KeepEmpty || quoted && "" != item ||
+			"" != strings.TrimSpace(item) {
+			items = append(items, item)
+		}
+		if i >= len(rs) {
+			return items, nil
+		}
+	}
+}
+
+// parseCsvQuoted parses the quoted item starting after the opening quote. It
+// returns the position of the separator following the item, or the end.
+func parseCsvQuoted(
+	rs []rune, i int, sep, escape rune, sb *strings.Builder,
+) (int, error) {
+	for ; i < len(rs); i++ {
+		if 0 != escape && escape == rs[i] && i+1 < len(rs) {
+			i++
+			sb.WriteRune(rs[i])
+			continue
+		}
+		if '"' != rs[i] {
+			sb.WriteRune(rs[i])
+			continue
+		}
+		if i+1 < len(rs) && '"' == rs[i+1] {
+			i++
+			sb.WriteRune('"')
+			continue
+		}
+		for i++; i < len(rs) && sep != rs[i]; i++ {
+			if !unicode.IsSpace(rs[i]) {
+				return i, ErrCsvUnexpectedQuote
+			}
+		}
+		return i, nil
+	}
+	return i, ErrCsvUnterminatedQuote
+}
+
+// parseCsvUnquoted parses the unquoted item starting at the given position.
+// It returns the position of the separator following the item, or the end.
+func parseCsvUnquoted(
+	rs []rune, i int, sep, escape rune, sb *strings.Builder,
+) int {
+	for ; i < len(rs) && sep != rs[i]; i++ {
+		if 0 != escape && escape == rs[i] && i+1 < len(rs) {
+			i++
+		}
+		sb.WriteRune(rs[i])
+	}
+	return i
+}
+
+// splitCsv splits the string by the separator without handling quotes and
+// escapes, trimming and removing empty items as specified by the options.
+func splitCsv(s string, opts CsvOptions) []string {
+	sep := opts.Separator
+	if 0 == sep {
+		sep = ','
+	}
+	items := []string{}
+	for _, item := range strings.Split(s, string(sep)) {
+		if !opts.KeepSpaces {
+			item = strings.TrimSpace(item)
+		}
+		if opts.KeepEmpty || "" != strings.TrimSpace(item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// FormatCsv joins the items into a string that `ParseCsv()` splits back into
+// the same items. Items containing separators, quotes, line breaks, or leading
+// or trailing spaces are quoted. Escape characters are escaped. Empty items
+// only survive parsing with `KeepEmpty`, which in turn parses an empty list as
+// a single empty item.
+func FormatCsv(items []string, opts CsvOptions) string {
+	sep := opts.Separator
+	if 0 == sep {
+		sep = ','
+	}
+	var sb strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteRune(sep)
+		}
+		if 0 != opts.Escape {
+			esc := string(opts.Escape)
+			item = strings.ReplaceAll(item, esc, esc+esc)
+		}
+		if strings.ContainsRune(item, sep) ||
+			strings.ContainsAny(item, "\"\r\n") ||
+			strings.TrimSpace(item) != item {
+			sb.WriteString(`"` + strings.ReplaceAll(item, `"`, `""`) + `"`)
+		} else {
+			sb.WriteString(item)
+		}
+	}
+	return sb.String()
+}
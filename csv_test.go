@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseCsv(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    CsvOptions
+		want    []string
+		wantErr error
+	}{
+		{"empty", "", CsvOptions{}, []string{}, nil},
+		{"simple", "a,b,c", CsvOptions{}, []string{"a", "b", "c"}, nil},
+		{
+			"trims spaces", " a , b ,c ", CsvOptions{},
+			[]string{"a", "b", "c"}, nil,
+		},
+		{
+			"removes empty", ",a,,b, ,\t,c,,", CsvOptions{},
+			[]string{"a", "b", "c"}, nil,
+		},
+		{
+			"quoted separator", `"a,b", c`, CsvOptions{},
+			[]string{"a,b", "c"}, nil,
+		},
+		{
+			"quoted quote", `"say ""hi""",x`, CsvOptions{},
+			[]string{`say "hi"`, "x"}, nil,
+		},
+		{
+			"quoted spaces kept", `" a ", b`, CsvOptions{},
+			[]string{" a ", "b"}, nil,
+		},
+		{
+			"quoted line break", "\"a\nb\",c", CsvOptions{},
+			[]string{"a\nb", "c"}, nil,
+		},
+		{
+			"quoted empty removed", `"",a`, CsvOptions{}, []string{"a"}, nil,
+		},
+		{
+			"escape", `a\,b,c\\d`, CsvOptions{Escape: '\\'},
+			[]string{"a,b", `c\d`}, nil,
+		},
+		{
+			"escape in quotes", `"a\"b"`, CsvOptions{Escape: '\\'},
+			[]string{`a"b`}, nil,
+		},
+		{
+			"semicolon", "a;b,c", CsvOptions{Separator: ';'},
+			[]string{"a", "b,c"}, nil,
+		},
+		{
+			"pipe", "a | b", CsvOptions{Separator: '|'},
+			[]string{"a", "b"}, nil,
+		},
+		{
+			"new line", "a\nb\n\nc\n", CsvOptions{Separator: '\n'},
+			[]string{"a", "b", "c"}, nil,
+		},
+		{
+			"keep spaces", " a , b", CsvOptions{KeepSpaces: true},
+			[]string{" a ", " b"}, nil,
+		},
+		{
+			"keep empty", "a,,b,", CsvOptions{KeepEmpty: true},
+			[]string{"a", "", "b", ""}, nil,
+		},
+		{
+			"unterminated quote", `a,"b`, CsvOptions{}, nil,
+			ErrCsvUnterminatedQuote,
+		},
+		{
+			"text after quote", `"a"b,c`, CsvOptions{}, nil,
+			ErrCsvUnexpectedQuote,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := ParseCsv(tt.input, tt.opts)
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func Test_GetEnvCsvWith(t *testing.T) {
+	t.Setenv("TEST_ENV_CSV_WITH", `a;"b;c"; d`)
+	got, err := GetEnvCsvWith(
+		"TEST_ENV_CSV_WITH", nil, CsvOptions{Separator: ';'},
+	)
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b;c", "d"}, got)
+	def := []string{"x"}
+	got, err = GetEnvCsvWith("TEST_ENV_CSV_WITH_MISSING", def, CsvOptions{})
+	require.Nil(t, err)
+	require.Equal(t, def, got)
+	t.Setenv("TEST_ENV_CSV_WITH", `"a`)
+	_, err = GetEnvCsvWith("TEST_ENV_CSV_WITH", def, CsvOptions{})
+	require.ErrorIs(t, err, ErrCsvUnterminatedQuote)
+	require.Equal(t, []string{`"a`}, GetEnvCsv("TEST_ENV_CSV_WITH", def))
+}
+
+func Test_Env_Csv_typed(t *testing.T) {
+	env := &Env{
+		Source: MapEnv{"PORTS": " 80 | 443 "},
+		Csv:    CsvOptions{Separator: '|'},
+	}
+	got, err := env.GetEnvUint16Csv("PORTS", nil)
+	require.Nil(t, err)
+	require.Equal(t, []uint16{80, 443}, got)
+}
+
+func Test_FormatCsv(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []string
+		opts  CsvOptions
+		want  string
+	}{
+		{"empty", nil, CsvOptions{}, ""},
+		{"simple", []string{"a", "b"}, CsvOptions{}, "a,b"},
+		{"separator", []string{"a,b", "c"}, CsvOptions{}, `"a,b",c`},
+		{"quote", []string{`say "hi"`}, CsvOptions{}, `"say ""hi"""`},
+		{"spaces", []string{" a", "b "}, CsvOptions{}, `" a","b "`},
+		{"line break", []string{"a\nb"}, CsvOptions{}, "\"a\nb\""},
+		{
+			"custom separator", []string{"a;b", "c,d"},
+			CsvOptions{Separator: ';'}, `"a;b";c,d`,
+		},
+		{
+			"escape", []string{`a\b`, "c,d"}, CsvOptions{Escape: '\\'},
+			`a\\b,"c,d"`,
+		},
+		{
+			"empty item", []string{"a", "", "b"}, CsvOptions{KeepEmpty: true},
+			"a,,b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got := FormatCsv(tt.items, tt.opts)
+				require.Equal(t, tt.want, got)
+				items, err := ParseCsv(got, tt.opts)
+				require.Nil(t, err)
+				require.Equal(t, len(tt.items), len(items))
+				if len(items) > 0 {
+					require.Equal(t, tt.items, items)
+				}
+			},
+		)
+	}
+}
+
+func Test_FormatCsv_empty_items_need_KeepEmpty(t *testing.T) {
+	got := FormatCsv([]string{"a", "", "b"}, CsvOptions{})
+	require.Equal(t, "a,,b", got)
+	items, err := ParseCsv(got, CsvOptions{})
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b"}, items)
+	opts := CsvOptions{KeepEmpty: true}
+	items, err = ParseCsv(FormatCsv(nil, opts), opts)
+	require.Nil(t, err)
+	require.Equal(t, []string{""}, items)
+}
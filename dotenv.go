@@ -0,0 +1,353 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DotEnvError reports a syntax error in a .env file.
+type DotEnvError struct {
+	// Name of the file, empty if the content was not read from a file
+	File string
+	// 1-based line number where the error was found
+	Line int
+	Err  error
+}
+
+func (e *DotEnvError) Error() string {
+	if "" == e.File {
+		return "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+	}
+	return e.File + ":" + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+}
+
+func (e *DotEnvError) Unwrap() error {
+	return e.Err
+}
+
+// ParseDotEnv parses the content of a .env file. The following syntax is
+// supported:
+//
+//   - blank lines and lines starting with `#` are ignored;
+//   - an optional `export ` prefix before the variable name;
+//   - unquoted values are trimmed, and ` #` starts an inline comment;
+//   - single-quoted values are taken literally;
+//   - double-quoted values may span multiple lines, and support `\n`, `\r`,
+//     `\t`, `\"`, `\\` and `\$` escapes;
+//   - `${VAR}`, `${VAR:-default}` and `${VAR:?error}` are expanded in
+//     unquoted and double-quoted values, see `Env.Expand`.
+//
+// Variables are expanded using values defined earlier in the content, then
+// from `env`, which may be `nil`.
+func ParseDotEnv(r io.Reader, env EnvSource) (MapEnv, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseDotEnv(string(content), env, false)
+}
+
+func parseDotEnv(content string, env EnvSource, preferEnv bool) (
+	MapEnv, error,
+) {
+	p := dotEnvParser{
+		src: content, line: 1, vars: MapEnv{}, env: env, preferEnv: preferEnv,
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.vars, nil
+}
+
+// ReadDotEnv parses the given .env files, or `.env` if no file is given, and
+// returns all variables as a `MapEnv`. Variables of later files override those
+// of earlier ones. Expansion falls back to the process environment. The
+// result can be used as a source of `Env`, e.g.
+// `NewEnv(ChainEnv{OsEnv{}, vars})`.
+func ReadDotEnv(files ...string) (MapEnv, error) {
+	if 0 == len(files) {
+		files = []string{".env"}
+	}
+	vars := MapEnv{}
+	for _, file := range files {
+		fv, err := readDotEnvFile(file, ChainEnv{vars, OsEnv{}}, false)
+		if err != nil {
+			return nil, err
+		}
+		for key, val := range fv {
+			vars[key] = val
+		}
+	}
+	return vars, nil
+}
+
+// LoadDotEnv parses the given .env files, or `.env` if no file is given, and
+// sets the variables in the process environment. Variables already present in
+// the process environment are not overridden, neither are variables set by
+// earlier files. Expansion prefers values of the process environment, so that
+// references resolve to the values actually in effect.
+func LoadDotEnv(files ...string) error {
+	if 0 == len(files) {
+		files = []string{".env"}
+	}
+	for _, file := range files {
+		vars, err := readDotEnvFile(file, OsEnv{}, true)
+		if err != nil {
+			return err
+		}
+		for _, key := range vars.Keys() {
+			if _, found := os.LookupEnv(key); found {
+				continue
+			}
+			if err = os.Setenv(key, vars[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readDotEnvFile(file string, env EnvSource, preferEnv bool) (
+	MapEnv, error,
+) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := parseDotEnv(string(content), env, preferEnv)
+	var de *DotEnvError
+	if errors.As(err, &de) {
+		de.File = file
+	}
+	return vars, err
+}
+
+type dotEnvParser struct {
+	src  string
+	pos  int
+	line int
+	vars MapEnv
+	env  EnvSource
+	// looks up variables from `env` before `vars`
+	preferEnv bool
+}
+
+func (p *dotEnvParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		if '#' == p.peek() {
+			p.skipLine()
+			continue
+		}
+		if err := p.parseLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *dotEnvParser) parseLine() error {
+	if strings.HasPrefix(p.src[p.pos:], "export ") ||
+		strings.HasPrefix(p.src[p.pos:], "export\t") {
+		p.pos += len("export")
+		p.skipSpaces()
+	}
+	key := p.parseKey()
+	if "" == key {
+		return p.error("invalid variable name")
+	}
+	p.skipSpaces()
+	if p.eof() || '=' != p.peek() {
+		return p.error("missing '=' after " + key)
+	}
+	p.pos++
+	p.skipSpaces()
+	var val string
+	var err error
+	switch {
+	case p.eof():
+	case '\'' == p.peek():
+		val, err = p.parseSingleQuoted()
+	case '"' == p.peek():
+		val, err = p.parseDoubleQuoted()
+	default:
+		val, err = p.parseUnquoted()
+	}
+	if err != nil {
+		return err
+	}
+	p.vars[key] = val
+	return nil
+}
+
+func (p *dotEnvParser) parseKey() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if '_' == c || '.' == c || 'a' <= c && c <= 'z' ||
+			'A' <= c && c <= 'Z' || p.pos > start && '0' <= c && c <= '9' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *dotEnvParser) parseUnquoted() (string, error) {
+	start := p.pos
+	p.skipLine()
+	val := p.src[start:p.pos]
+	if strings.HasPrefix(val, "#") {
+		return "", nil
+	}
+	if idx := strings.IndexAny(val, " \t"); idx > -1 {
+		for i := idx; i < len(val); i++ {
+			if '#' == val[i] && (' ' == val[i-1] || '\t' == val[i-1]) {
+				val = val[:i]
+				break
+			}
+		}
+	}
+	return p.expand(strings.TrimSpace(val), `$`)
+}
+
+func (p *dotEnvParser) parseSingleQuoted() (string, error) {
+	p.pos++
+	start := p.pos
+	for !p.eof() && '\'' != p.peek() && '\n' != p.peek() {
+		p.pos++
+	}
+	if p.eof() || '\'' != p.peek() {
+		return "", p.error("unterminated single-quoted value")
+	}
+	val := p.src[start:p.pos]
+	p.pos++
+	return val, p.endQuoted()
+}
+
+func (p *dotEnvParser) parseDoubleQuoted() (string, error) {
+	line := p.line
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			p.line = line
+			return "", p.error("unterminated double-quoted value")
+		}
+		c := p.peek()
+		p.pos++
+		if '"' == c {
+			break
+		}
+		if '\n' == c {
+			p.line++
+		}
+		if '\\' != c || p.eof() {
+			sb.WriteByte(c)
+			continue
+		}
+		switch e := p.peek(); e {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '$', '\\':
+			// keeps the escape for the expansion, so `\\${X}` is a backslash
+			// followed by the value of X
+			sb.WriteByte(c)
+			sb.WriteByte(e)
+		case '"':
+			sb.WriteByte(e)
+		default:
+			sb.WriteByte(c)
+			continue
+		}
+		p.pos++
+	}
+	val, err := p.expand(sb.String(), `$\`)
+	if err != nil {
+		return "", err
+	}
+	return val, p.endQuoted()
+}
+
+// endQuoted makes sure only spaces or a comment follow a quoted value.
+func (p *dotEnvParser) endQuoted() error {
+	p.skipSpaces()
+	if p.eof() || '\n' == p.peek() || '\r' == p.peek() {
+		return nil
+	}
+	if '#' == p.peek() {
+		p.skipLine()
+		return nil
+	}
+	return p.error("unexpected character after quoted value")
+}
+
+// expand replaces references in the value, a backslash followed by one of the
+// `escaped` characters being taken literally.
+func (p *dotEnvParser) expand(val, escaped string) (string, error) {
+	ret, err := expandEnvVars(
+		val, escaped, func(key string) (string, bool, error) {
+			if p.preferEnv && nil != p.env {
+				if v, found := p.env.LookupEnv(key); found {
+					return v, true, nil
+				}
+			}
+			if v, found := p.vars[key]; found {
+				return v, true, nil
+			}
+			if nil == p.env {
+				return "", false, nil
+			}
+			v, found := p.env.LookupEnv(key)
+			return v, found, nil
+		},
+	)
+	if err != nil {
+		return "", &DotEnvError{Line: p.line, Err: err}
+	}
+	return ret, nil
+}
+
+func (p *dotEnvParser) error(msg string) error {
+	return &DotEnvError{Line: p.line, Err: errors.New(msg)}
+}
+
+func (p *dotEnvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotEnvParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *dotEnvParser) skipSpaces() {
+	for !p.eof() && (' ' == p.peek() || '\t' == p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *dotEnvParser) skipBlank() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.peek()) > -1 {
+		if '\n' == p.peek() {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+// skipLine moves to the end of current line, leaving the line break.
+func (p *dotEnvParser) skipLine() {
+	for !p.eof() && '\n' != p.peek() {
+		p.pos++
+	}
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func chdirTemp(tb testing.TB) {
+	wd, err := os.Getwd()
+	require.Nil(tb, err)
+	require.Nil(tb, os.Chdir(tb.TempDir()))
+	tb.Cleanup(func() { require.Nil(tb, os.Chdir(wd)) })
+}
+
+// writeTestFile writes the content to the named file in the directory,
+// readable by the owner only, and returns the path of the file.
+func writeTestFile[T string | []byte](
+	tb testing.TB, dir, name string, content T,
+) string {
+	file := filepath.Join(dir, name)
+	require.Nil(tb, os.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func Test_ParseDotEnv(t *testing.T) {
+	content := `# comment
+EMPTY=
+COMMENT= # only a comment
+PLAIN = plain value  # inline comment
+HASH=a#b
+export EXPORTED=yes
+	export	TABBED=tab
+SINGLE='literal ${PLAIN} # not comment'
+DOUBLE="line1\nline2 \"quoted\" \$HOME \\ \x"
+MULTI="first
+second" # comment
+EXPANDED=${PLAIN}/${FROM_ENV}/${MISSING}
+DEFAULT=${MISSING:-fallback}/${EMPTY:-empty}/${PLAIN:-unused}
+QUOTED_EXPANDED="${EXPORTED}-${MISSING:-x}"
+BACKSLASH_REF="\\${EXPORTED} \\\${EXPORTED} ${MISSING:-a\\b}"
+WINDOWS=crlf` + "\r\n" + `dotted.key=dot
+`
+	vars, err := ParseDotEnv(
+		strings.NewReader(content), MapEnv{"FROM_ENV": "env"},
+	)
+	require.Nil(t, err)
+	require.Equal(
+		t, MapEnv{
+			"EMPTY":           "",
+			"COMMENT":         "",
+			"PLAIN":           "plain value",
+			"HASH":            "a#b",
+			"EXPORTED":        "yes",
+			"TABBED":          "tab",
+			"SINGLE":          "literal ${PLAIN} # not comment",
+			"DOUBLE":          "line1\nline2 \"quoted\" $HOME \\ \\x",
+			"MULTI":           "first\nsecond",
+			"EXPANDED":        "plain value/env/",
+			"DEFAULT":         "fallback/empty/plain value",
+			"QUOTED_EXPANDED": "yes-x",
+			"BACKSLASH_REF":   `\yes \${EXPORTED} a\b`,
+			"WINDOWS":         "crlf",
+			"dotted.key":      "dot",
+		}, vars,
+	)
+}
+
+func Test_ParseDotEnv_without_env(t *testing.T) {
+	vars, err := ParseDotEnv(strings.NewReader("A=${HOME}"), nil)
+	require.Nil(t, err)
+	require.Equal(t, MapEnv{"A": ""}, vars)
+}
+
+func Test_ParseDotEnv_returns_line_numbered_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"invalid name", "A=1\n\n1A=2", "line 3: invalid variable name"},
+		{"missing equal sign", "A=1\nB", "line 2: missing '=' after B"},
+		{"missing equal sign before value", "B 1", "line 1: missing '=' after B"},
+		{
+			"unterminated single quote", "A='abc\nB=1",
+			"line 1: unterminated single-quoted value",
+		},
+		{
+			"unterminated double quote", "X=1\nA=\"abc\nB=1",
+			"line 2: unterminated double-quoted value",
+		},
+		{
+			"trailing characters", "A=\"abc\" d",
+			"line 1: unexpected character after quoted value",
+		},
+		{
+			"unterminated reference", "A=1\nB=${A",
+			"line 2: unterminated variable reference",
+		},
+		{
+			"empty reference", "A=\"a\nb${}\"",
+			"line 2: empty variable reference",
+		},
+		{
+			"unset reference", "A=${B:?B is required}",
+			"line 1: referenced variable not set or empty: B: B is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := ParseDotEnv(strings.NewReader(tt.content), nil)
+				require.EqualError(t, err, tt.want)
+				var de *DotEnvError
+				require.ErrorAs(t, err, &de)
+			},
+		)
+	}
+}
+
+func Test_ParseDotEnv_wraps_expansion_errors(t *testing.T) {
+	_, err := ParseDotEnv(strings.NewReader("A=\"${B:?}\""), nil)
+	require.ErrorIs(t, err, ErrEnvUnsetRef)
+	var de *DotEnvError
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, 1, de.Line)
+}
+
+func Test_ParseDotEnv_returns_reader_error(t *testing.T) {
+	f, err := os.Open(t.TempDir())
+	require.Nil(t, err)
+	defer f.Close()
+	_, err = ParseDotEnv(f, nil)
+	require.NotNil(t, err)
+}
+
+func Test_ReadDotEnv(t *testing.T) {
+	t.Setenv("TEST_DOTENV_OS", "os")
+	f1 := writeTestFile(t, t.TempDir(), ".env", "A=1\nB=${TEST_DOTENV_OS}")
+	f2 := writeTestFile(t, t.TempDir(), ".env", "A=2\nC=${A}${B}")
+	vars, err := ReadDotEnv(f1, f2)
+	require.Nil(t, err)
+	require.Equal(t, MapEnv{"A": "2", "B": "os", "C": "2os"}, vars)
+	env := NewEnv(ChainEnv{OsEnv{}, vars})
+	require.Equal(t, "2os", env.GetEnvWithDefault("C", ""))
+}
+
+func Test_ReadDotEnv_reports_file_name(t *testing.T) {
+	f := writeTestFile(t, t.TempDir(), ".env", "A=1\nB")
+	_, err := ReadDotEnv(f)
+	require.EqualError(t, err, f+":2: missing '=' after B")
+}
+
+func Test_ReadDotEnv_defaults_to_dot_env(t *testing.T) {
+	chdirTemp(t)
+	require.Nil(t, os.WriteFile(".env", []byte("A=1"), 0600))
+	vars, err := ReadDotEnv()
+	require.Nil(t, err)
+	require.Equal(t, MapEnv{"A": "1"}, vars)
+}
+
+func Test_ReadDotEnv_returns_error_if_file_missing(t *testing.T) {
+	_, err := ReadDotEnv(filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_LoadDotEnv(t *testing.T) {
+	t.Setenv("TEST_DOTENV_EXISTING", "os")
+	t.Setenv("TEST_DOTENV_NEW", "")
+	require.Nil(t, os.Unsetenv("TEST_DOTENV_NEW"))
+	f1 := writeTestFile(
+		t, t.TempDir(), ".env",
+		"TEST_DOTENV_EXISTING=file\nTEST_DOTENV_NEW=${TEST_DOTENV_EXISTING}",
+	)
+	f2 := writeTestFile(t, t.TempDir(), ".env", "TEST_DOTENV_NEW=second")
+	require.Nil(t, LoadDotEnv(f1, f2))
+	require.Equal(t, "os", os.Getenv("TEST_DOTENV_EXISTING"))
+	require.Equal(t, "os", os.Getenv("TEST_DOTENV_NEW"))
+}
+
+func Test_LoadDotEnv_defaults_to_dot_env(t *testing.T) {
+	chdirTemp(t)
+	t.Setenv("TEST_DOTENV_DEFAULT", "")
+	require.Nil(t, os.Unsetenv("TEST_DOTENV_DEFAULT"))
+	require.Nil(t, os.WriteFile(".env", []byte("TEST_DOTENV_DEFAULT=1"), 0600))
+	require.Nil(t, LoadDotEnv())
+	require.Equal(t, "1", os.Getenv("TEST_DOTENV_DEFAULT"))
+}
+
+func Test_LoadDotEnv_returns_error(t *testing.T) {
+	f := writeTestFile(t, t.TempDir(), ".env", "A")
+	require.NotNil(t, LoadDotEnv(f))
+}
@@ -1,17 +1,69 @@
 package utils
 
 import (
+	"errors"
 	"os"
-	"slices"
 	"strconv"
 	"strings"
 )
 
+// EnvFileSuffix is appended to variable names to get the name of the variable
+// holding the path of the secret file.
+const EnvFileSuffix = "_FILE"
+
+var ErrEnvFileConflict = errors.New("both variable and its _FILE counterpart are set")
+
+// DefaultEnv is the `Env` used by package level GetEnv* functions. It reads
+// variables from the process environment.
+var DefaultEnv = NewEnv(OsEnv{})
+
+// Env reads environment variables from a source. All package level GetEnv*
+// functions are available as methods, reading from `Source` instead of the
+// process environment.
+type Env struct {
+	// Where variables are read from
+	Source EnvSource
+	// Prepended to all keys read from `Source`, see `Scope()`
+	Prefix string
+	// Records names of read variables, see `FindUnknown()`. Nothing is
+	// recorded if it is `nil`. Scopes share the usage of their parent.
+	Usage *EnvUsage
+	// Variables declared for documentation, see `Declare()`. Scopes share the
+	// declarations of their parent.
+	Declared *EnvVarSet
+	// Reads secrets from files referenced by `<KEY>_FILE` variables, following
+	// the Docker and Kubernetes convention. See `Lookup()`.
+	FileSecrets bool
+	// How values are split by GetEnv*Csv methods
+	Csv CsvOptions
+	// How values are parsed by GetEnv*Map methods
+	Map MapOptions
+	// Lenient parsing of booleans and integers, values are parsed strictly if
+	// it is zero
+	Parse EnvParseMode
+	// Rejects unknown object fields of JSON values, see `GetEnvJsonFrom()`
+	StrictJson bool
+	// Expands `${VAR}`, `${VAR:-default}` and `${VAR:?error}` references in
+	// values, see `Lookup()`
+	Expand bool
+	// Decrypts `ENC(...)` values, see `SealEnv()`. Values are returned as is
+	// if it is `nil`.
+	MasterKey []byte
+}
+
+// NewEnv returns a new `Env` reading variables from the given source, recording
+// names of read and declared variables.
+func NewEnv(source EnvSource) *Env {
+	return &Env{
+		Source: source, Usage: NewEnvUsage(), Declared: NewEnvVarSet(),
+	}
+}
+
 // GetEnvWithDefault returns the value of the environment variable named by
 // the key. It is guaranteed to return the default value if the environment
 // variable is not found.
 func GetEnvWithDefault(key, defaultValue string) string {
-	return GetEnv(key, defaultValue, false)
+	return DefaultEnv.GetEnvWithDefault(key, defaultValue)
 }
 
 // GetEnvWithDefaultNE returns the value of the environment variable named by
@@ -19,7 +71,7 @@ func GetEnvWithDefault(key, defaultValue string) string {
 // variable is not found. If the environment variable is found, it is guaranteed
 // to return the default value if the environment variable is not found or empty.
 func GetEnvWithDefaultNE(key, defaultValue string) string {
-	return GetEnv(key, defaultValue, true)
+	return DefaultEnv.GetEnvWithDefaultNE(key, defaultValue)
 }
 
 // GetEnv returns the value of the environment variable named by
@@ -28,140 +80,106 @@ func GetEnvWithDefaultNE(key, defaultValue string) string {
 // to return the `defaultValue` if the environment variable is empty and
 // `nonEmpty` is `true`.
 func GetEnv(key, defaultValue string, nonEmpty bool) string {
-	val, found := os.LookupEnv(key)
-	if !found {
-		return defaultValue
-	}
-	if nonEmpty && "" == val {
-		return defaultValue
-	}
-	return val
+	return DefaultEnv.GetEnv(key, defaultValue, nonEmpty)
 }
 
 // MustGetEnv returns the value of the environment variable named by
 // the key. It panics if the environment variable is not found.
 func MustGetEnv(key string) string {
-	val, found := os.LookupEnv(key)
-	if !found {
-		panic("missing environment variable: " + key)
-	}
-	return val
+	return DefaultEnv.MustGetEnv(key)
 }
 
 // MustGetEnvNE returns the value of the environment variable named by
 // the key. It panics if the environment variable is not found or empty.
 func MustGetEnvNE(key string) string {
-	val := os.Getenv(key)
-	if "" == val {
-		panic("missing environment variable: " + key)
-	}
-	return val
+	return DefaultEnv.MustGetEnvNE(key)
 }
 
 // GetEnvCsv returns the value of the environment variable named by
 // the key as a slice of strings. It is guaranteed to return the default
-// value if the environment variable is not found.
+// value if the environment variable is not found. See `ParseCsv()` for the
+// format of the value, malformed quotes are kept as-is.
 func GetEnvCsv(key string, defaultValue []string) []string {
-	val := os.Getenv(key)
-	if "" == val {
-		return defaultValue
-	}
-	return slices.DeleteFunc(
-		strings.Split(val, ","),
-		func(s string) bool { return "" == strings.TrimSpace(s) },
-	)
+	return DefaultEnv.GetEnvCsv(key, defaultValue)
+}
+
+// GetEnvCsvWith returns the value of the environment variable named by
+// the key as a slice of strings split as specified by `opts`. It is guaranteed
+// to return the default value if the environment variable is not found.
+func GetEnvCsvWith(key string, defaultValue []string, opts CsvOptions) (
+	[]string, error,
+) {
+	return DefaultEnv.GetEnvCsvWith(key, defaultValue, opts)
 }
 
 // GetEnvInt returns the value of the environment variable named by
 // the key as an int. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvInt(key string, defaultValue int64, bitSize int) (int64, error) {
-	val := os.Getenv(key)
-	if "" == val {
-		return defaultValue, nil
-	}
-	ret, err := strconv.ParseInt(val, 10, bitSize)
-	if err != nil {
-		return 0, err
-	}
-	return ret, nil
+	return DefaultEnv.GetEnvInt(key, defaultValue, bitSize)
 }
 
 // GetEnvInt8 returns the value of the environment variable named by
 // the key as an int8. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvInt8(key string, defaultValue int8) (int8, error) {
-	ret, err := GetEnvInt(key, int64(defaultValue), 8)
-	return int8(ret), err
+	return DefaultEnv.GetEnvInt8(key, defaultValue)
 }
 
 // GetEnvInt16 returns the value of the environment variable named by
 // the key as an int16. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvInt16(key string, defaultValue int16) (int16, error) {
-	ret, err := GetEnvInt(key, int64(defaultValue), 16)
-	return int16(ret), err
+	return DefaultEnv.GetEnvInt16(key, defaultValue)
 }
 
 // GetEnvInt32 returns the value of the environment variable named by
 // the key as an int32. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvInt32(key string, defaultValue int32) (int32, error) {
-	ret, err := GetEnvInt(key, int64(defaultValue), 32)
-	return int32(ret), err
+	return DefaultEnv.GetEnvInt32(key, defaultValue)
 }
 
 // GetEnvInt64 returns the value of the environment variable named by
 // the key as an int64. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvInt64(key string, defaultValue int64) (int64, error) {
-	return GetEnvInt(key, defaultValue, 64)
+	return DefaultEnv.GetEnvInt64(key, defaultValue)
 }
 
 // GetEnvUint returns the value of the environment variable named by
 // the key as an uint. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvUint(key string, defaultValue uint64, bitSize int) (uint64, error) {
-	val := os.Getenv(key)
-	if "" == val {
-		return defaultValue, nil
-	}
-	ret, err := strconv.ParseUint(val, 10, bitSize)
-	if err != nil {
-		return 0, err
-	}
-	return ret, nil
+	return DefaultEnv.GetEnvUint(key, defaultValue, bitSize)
 }
 
 // GetEnvUint8 returns the value of the environment variable named by
 // the key as an uint8. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvUint8(key string, defaultValue uint8) (uint8, error) {
-	ret, err := GetEnvUint(key, uint64(defaultValue), 8)
-	return uint8(ret), err
+	return DefaultEnv.GetEnvUint8(key, defaultValue)
 }
 
 // GetEnvUint16 returns the value of the environment variable named by
 // the key as an uint16. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvUint16(key string, defaultValue uint16) (uint16, error) {
-	ret, err := GetEnvUint(key, uint64(defaultValue), 16)
-	return uint16(ret), err
+	return DefaultEnv.GetEnvUint16(key, defaultValue)
 }
 
 // GetEnvUint32 returns the value of the environment variable named by
 // the key as an uint32. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvUint32(key string, defaultValue uint32) (uint32, error) {
-	ret, err := GetEnvUint(key, uint64(defaultValue), 32)
-	return uint32(ret), err
+	return DefaultEnv.GetEnvUint32(key, defaultValue)
 }
 
 // GetEnvUint64 returns the value of the environment variable named by
 // the key as an uint64. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvUint64(key string, defaultValue uint64) (uint64, error) {
-	return GetEnvUint(key, defaultValue, 64)
+	return DefaultEnv.GetEnvUint64(key, defaultValue)
 }
 
 // GetEnvFloat returns the value of the environment variable named by
@@ -170,45 +188,28 @@ func GetEnvUint64(key string, defaultValue uint64) (uint64, error) {
 func GetEnvFloat(key string, defaultValue float64, bitSize int) (
 	float64, error,
 ) {
-	val := os.Getenv(key)
-	if "" == val {
-		return defaultValue, nil
-	}
-	ret, err := strconv.ParseFloat(val, bitSize)
-	if err != nil {
-		return 0, err
-	}
-	return ret, nil
+	return DefaultEnv.GetEnvFloat(key, defaultValue, bitSize)
 }
 
 // GetEnvFloat32 returns the value of the environment variable named by
 // the key as a float32. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvFloat32(key string, defaultValue float32) (float32, error) {
-	ret, err := GetEnvFloat(key, float64(defaultValue), 32)
-	return float32(ret), err
+	return DefaultEnv.GetEnvFloat32(key, defaultValue)
 }
 
 // GetEnvFloat64 returns the value of the environment variable named by
 // the key as a float64. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvFloat64(key string, defaultValue float64) (float64, error) {
-	return GetEnvFloat(key, defaultValue, 64)
+	return DefaultEnv.GetEnvFloat64(key, defaultValue)
 }
 
 // GetEnvBool returns the value of the environment variable named by
 // the key as a bool. It is guaranteed to return the default value if
 // the environment variable is not found or empty.
 func GetEnvBool(key string, defaultValue bool) (bool, error) {
-	val := GetEnvWithDefault(key, "")
-	if "" == val {
-		return defaultValue, nil
-	}
-	ret, err := strconv.ParseBool(val)
-	if err != nil {
-		return false, err
-	}
-	return ret, nil
+	return DefaultEnv.GetEnvBool(key, defaultValue)
 }
 
 // GetEnvIntCsv returns the value of the environment variable named by
@@ -217,29 +218,464 @@ func GetEnvBool(key string, defaultValue bool) (bool, error) {
 func GetEnvIntCsv(key string, defaultValue []int64, bitSize int) (
 	[]int64, error,
 ) {
-	ss := GetEnvCsv(key, nil)
+	return DefaultEnv.GetEnvIntCsv(key, defaultValue, bitSize)
+}
+
+// GetEnvInt8Csv returns the value of the environment variable named by
+// the key as a slice of int8s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvInt8Csv(key string, defaultValue []int8) ([]int8, error) {
+	return DefaultEnv.GetEnvInt8Csv(key, defaultValue)
+}
+
+// GetEnvInt16Csv returns the value of the environment variable named by
+// the key as a slice of int16s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvInt16Csv(key string, defaultValue []int16) ([]int16, error) {
+	return DefaultEnv.GetEnvInt16Csv(key, defaultValue)
+}
+
+// GetEnvInt32Csv returns the value of the environment variable named by
+// the key as a slice of int32s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvInt32Csv(key string, defaultValue []int32) ([]int32, error) {
+	return DefaultEnv.GetEnvInt32Csv(key, defaultValue)
+}
+
+// GetEnvInt64Csv returns the value of the environment variable named by
+// the key as a slice of int64s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvInt64Csv(key string, defaultValue []int64) ([]int64, error) {
+	return DefaultEnv.GetEnvInt64Csv(key, defaultValue)
+}
+
+// GetEnvUintCsv returns the value of the environment variable named by
+// the key as a slice of uints. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvUintCsv(key string, defaultValue []uint64, bitSize int) (
+	[]uint64, error,
+) {
+	return DefaultEnv.GetEnvUintCsv(key, defaultValue, bitSize)
+}
+
+// GetEnvUint8Csv returns the value of the environment variable named by
+// the key as a slice of uint8s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvUint8Csv(key string, defaultValue []uint8) ([]uint8, error) {
+	return DefaultEnv.GetEnvUint8Csv(key, defaultValue)
+}
+
+// GetEnvUint16Csv returns the value of the environment variable named by
+// the key as a slice of uint16s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvUint16Csv(key string, defaultValue []uint16) ([]uint16, error) {
+	return DefaultEnv.GetEnvUint16Csv(key, defaultValue)
+}
+
+// GetEnvUint32Csv returns the value of the environment variable named by
+// the key as a slice of uint32s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvUint32Csv(key string, defaultValue []uint32) ([]uint32, error) {
+	return DefaultEnv.GetEnvUint32Csv(key, defaultValue)
+}
+
+// GetEnvUint64Csv returns the value of the environment variable named by
+// the key as a slice of uint64s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvUint64Csv(key string, defaultValue []uint64) ([]uint64, error) {
+	return DefaultEnv.GetEnvUint64Csv(key, defaultValue)
+}
+
+// GetEnvFloatCsv returns the value of the environment variable named by
+// the key as a slice of floats. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvFloatCsv(key string, defaultValue []float64, bitSize int) (
+	[]float64, error,
+) {
+	return DefaultEnv.GetEnvFloatCsv(key, defaultValue, bitSize)
+}
+
+// GetEnvFloat32Csv returns the value of the environment variable named by
+// the key as a slice of float32s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvFloat32Csv(key string, defaultValue []float32) ([]float32, error) {
+	return DefaultEnv.GetEnvFloat32Csv(key, defaultValue)
+}
+
+// GetEnvFloat64Csv returns the value of the environment variable named by
+// the key as a slice of float64s. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvFloat64Csv(key string, defaultValue []float64) ([]float64, error) {
+	return DefaultEnv.GetEnvFloat64Csv(key, defaultValue)
+}
+
+// GetEnvBoolCsv returns the value of the environment variable named by
+// the key as a slice of bools. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvBoolCsv(key string, defaultValue []bool) ([]bool, error) {
+	return DefaultEnv.GetEnvBoolCsv(key, defaultValue)
+}
+
+// Lookup retrieves the value of the variable named by the key. The returned
+// boolean is `false` if the variable is not present. If `FileSecrets` is
+// enabled and the variable is not set, the value is read from the file named by
+// the `<KEY>_FILE` variable, with the trailing line break removed. An error is
+// returned if both variables are set or the file cannot be read. The key is
+// prefixed with `Prefix`.
+//
+// If `Expand` is enabled, references in the value are replaced by values of
+// the referenced variables, read the same way and expanded recursively.
+// References use full names, without `Prefix`:
+//
+//   - `${VAR}` is replaced by the value of `VAR`, or empty if it is not set;
+//   - `${VAR:-default}` uses the default if `VAR` is not set or empty, the
+//     default may contain references;
+//   - `${VAR:?message}` returns an error wrapping `ErrEnvUnsetRef` if `VAR` is
+//     not set or empty;
+//   - `\$` produces a literal dollar sign.
+//
+// An error wrapping `ErrEnvCycle` is returned if a variable references itself,
+// directly or indirectly.
+//
+// If `MasterKey` is set, `ENC(...)` values, including those of referenced
+// variables, are decrypted by `OpenEnv()`. Values holding decrypted data,
+// including values expanded from decrypted references, are redacted in errors
+// returned by getters, along with the message of the cause.
+func (e *Env) Lookup(key string) (string, bool, error) {
+	val, found, _, err := e.lookupScoped(key)
+	return val, found, err
+}
+
+// lookupScoped is the same as `Lookup()`, also reporting whether the value
+// holds decrypted data.
+func (e *Env) lookupScoped(key string) (string, bool, bool, error) {
+	if e.Expand {
+		return e.lookupExpanded(e.Prefix+key, nil)
+	}
+	return e.lookup(e.Prefix + key)
+}
+
+// lookup retrieves the value of the variable named by the full key, decrypted
+// if it is an `ENC(...)` envelope, without expanding references. The third
+// returned value reports whether the value has been decrypted.
+func (e *Env) lookup(key string) (string, bool, bool, error) {
+	val, found, err := e.read(key)
+	if err != nil || nil == e.MasterKey || !IsEnvEnvelope(val) {
+		return val, found, false, err
+	}
+	plain, err := OpenEnv(e.MasterKey, key, val)
+	if err != nil {
+		return "", false, false, newEnvError(key, val, "", err)
+	}
+	return plain, true, true, nil
+}
+
+// read retrieves the raw value of the variable named by the full key, from
+// `Source` or the secret file.
+func (e *Env) read(key string) (string, bool, error) {
+	if nil != e.Usage {
+		e.Usage.add(key)
+	}
+	val, found := e.Source.LookupEnv(key)
+	if !e.FileSecrets {
+		return val, found, nil
+	}
+	if nil != e.Usage {
+		e.Usage.add(key + EnvFileSuffix)
+	}
+	file, _ := e.Source.LookupEnv(key + EnvFileSuffix)
+	if "" == file {
+		return val, found, nil
+	}
+	if "" != val {
+		return "", false, newEnvError(key, val, "", ErrEnvFileConflict)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, newEnvError(key+EnvFileSuffix, file, "", err)
+	}
+	val = strings.TrimSuffix(string(content), "\n")
+	return strings.TrimSuffix(val, "\r"), true, nil
+}
+
+// GetEnvWithDefault returns the value of the variable named by the key, or
+// the default value if the variable is not found.
+func (e *Env) GetEnvWithDefault(key, defaultValue string) string {
+	return e.GetEnv(key, defaultValue, false)
+}
+
+// GetEnvWithDefaultNE returns the value of the variable named by the key, or
+// the default value if the variable is not found or empty.
+func (e *Env) GetEnvWithDefaultNE(key, defaultValue string) string {
+	return e.GetEnv(key, defaultValue, true)
+}
+
+// GetEnv returns the value of the variable named by the key. It returns the
+// `defaultValue` if the variable is not found, or if it is empty and
+// `nonEmpty` is `true`. It panics if `Lookup()` returns an error.
+func (e *Env) GetEnv(key, defaultValue string, nonEmpty bool) string {
+	val, found, err := e.Lookup(key)
+	PanicIfError(err)
+	if !found {
+		return defaultValue
+	}
+	if nonEmpty && "" == val {
+		return defaultValue
+	}
+	return val
+}
+
+// MustGetEnv returns the value of the variable named by the key. It panics if
+// the variable is not found, or `Lookup()` returns an error.
+func (e *Env) MustGetEnv(key string) string {
+	val, found, err := e.Lookup(key)
+	PanicIfError(err)
+	if !found {
+		panic("missing environment variable: " + key)
+	}
+	return val
+}
+
+// MustGetEnvNE returns the value of the variable named by the key. It panics
+// if the variable is not found or empty, or `Lookup()` returns an error.
+func (e *Env) MustGetEnvNE(key string) string {
+	val, _, err := e.Lookup(key)
+	PanicIfError(err)
+	if "" == val {
+		panic("missing environment variable: " + key)
+	}
+	return val
+}
+
+// GetEnvCsv returns the value of the variable named by the key as a slice of
+// strings, or the default value if the variable is not found or empty. Values
+// are split as specified by `Csv`. Malformed quoted items are not an error,
+// the value is then split by the separator as-is. It panics if `Lookup()`
+// returns an error.
+func (e *Env) GetEnvCsv(key string, defaultValue []string) []string {
+	val, err := e.value(key)
+	PanicIfError(err)
+	if "" == val {
+		return defaultValue
+	}
+	ss, err := ParseCsv(val, e.Csv)
+	if err != nil {
+		return splitCsv(val, e.Csv)
+	}
+	return ss
+}
+
+// GetEnvCsvWith returns the value of the variable named by the key as a slice
+// of strings split as specified by `opts`, or the default value if the
+// variable is not found or empty.
+func (e *Env) GetEnvCsvWith(
+	key string, defaultValue []string, opts CsvOptions,
+) ([]string, error) {
+	ss, err := e.csv(key, opts)
+	if err != nil {
+		return nil, err
+	}
 	if nil == ss {
 		return defaultValue, nil
 	}
-	vs, err := SliceMapFuncE[[]int64](
-		ss, func(s string) (int64, error) {
-			return strconv.ParseInt(s, 10, bitSize)
+	return ss, nil
+}
+
+// value returns the value of the variable named by the key, or empty string
+// if the variable is not found.
+func (e *Env) value(key string) (string, error) {
+	val, _, err := e.Lookup(key)
+	return val, err
+}
+
+// peek returns the value of the variable named by the key the same way getters
+// read it, without recording usage. It is used to report the value in errors.
+func (e *Env) peek(key string) string {
+	src := *e
+	src.Usage = nil
+	val, _ := src.value(key)
+	return val
+}
+
+// csv returns the value of the variable named by the key split into a slice,
+// or `nil` if the variable is not found or empty.
+func (e *Env) csv(key string, opts CsvOptions) ([]string, error) {
+	val, err := e.value(key)
+	if err != nil || "" == val {
+		return nil, err
+	}
+	ss, err := ParseCsv(val, opts)
+	if err != nil {
+		return nil, e.envError(key, val, "[]string", err)
+	}
+	return ss, nil
+}
+
+// GetEnvInt returns the value of the variable named by the key as an int, or
+// the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt(key string, defaultValue int64, bitSize int) (
+	int64, error,
+) {
+	ret, ok, err := parseEnvScalar(
+		e, key, intTypeName("int", bitSize), func(s string) (int64, error) {
+			return ParseEnvInt(s, bitSize, e.Parse)
 		},
 	)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvInt8 returns the value of the variable named by the key as an int8,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt8(key string, defaultValue int8) (int8, error) {
+	ret, err := e.GetEnvInt(key, int64(defaultValue), 8)
+	return int8(ret), err
+}
+
+// GetEnvInt16 returns the value of the variable named by the key as an int16,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt16(key string, defaultValue int16) (int16, error) {
+	ret, err := e.GetEnvInt(key, int64(defaultValue), 16)
+	return int16(ret), err
+}
+
+// GetEnvInt32 returns the value of the variable named by the key as an int32,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt32(key string, defaultValue int32) (int32, error) {
+	ret, err := e.GetEnvInt(key, int64(defaultValue), 32)
+	return int32(ret), err
+}
+
+// GetEnvInt64 returns the value of the variable named by the key as an int64,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt64(key string, defaultValue int64) (int64, error) {
+	return e.GetEnvInt(key, defaultValue, 64)
+}
+
+// GetEnvUint returns the value of the variable named by the key as an uint,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUint(key string, defaultValue uint64, bitSize int) (
+	uint64, error,
+) {
+	ret, ok, err := parseEnvScalar(
+		e, key, intTypeName("uint", bitSize), func(s string) (uint64, error) {
+			return ParseEnvUint(s, bitSize, e.Parse)
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvUint8 returns the value of the variable named by the key as an uint8,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUint8(key string, defaultValue uint8) (uint8, error) {
+	ret, err := e.GetEnvUint(key, uint64(defaultValue), 8)
+	return uint8(ret), err
+}
+
+// GetEnvUint16 returns the value of the variable named by the key as an
+// uint16, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUint16(key string, defaultValue uint16) (uint16, error) {
+	ret, err := e.GetEnvUint(key, uint64(defaultValue), 16)
+	return uint16(ret), err
+}
+
+// GetEnvUint32 returns the value of the variable named by the key as an
+// uint32, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUint32(key string, defaultValue uint32) (uint32, error) {
+	ret, err := e.GetEnvUint(key, uint64(defaultValue), 32)
+	return uint32(ret), err
+}
+
+// GetEnvUint64 returns the value of the variable named by the key as an
+// uint64, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUint64(key string, defaultValue uint64) (uint64, error) {
+	return e.GetEnvUint(key, defaultValue, 64)
+}
+
+// GetEnvFloat returns the value of the variable named by the key as a float,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvFloat(key string, defaultValue float64, bitSize int) (
+	float64, error,
+) {
+	ret, ok, err := parseEnvScalar(
+		e, key, intTypeName("float", bitSize), func(s string) (float64, error) {
+			return strconv.ParseFloat(s, bitSize)
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvFloat32 returns the value of the variable named by the key as a
+// float32, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvFloat32(key string, defaultValue float32) (float32, error) {
+	ret, err := e.GetEnvFloat(key, float64(defaultValue), 32)
+	return float32(ret), err
+}
+
+// GetEnvFloat64 returns the value of the variable named by the key as a
+// float64, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvFloat64(key string, defaultValue float64) (float64, error) {
+	return e.GetEnvFloat(key, defaultValue, 64)
+}
+
+// GetEnvBool returns the value of the variable named by the key as a bool,
+// or the default value if the variable is not found or empty.
+func (e *Env) GetEnvBool(key string, defaultValue bool) (bool, error) {
+	ret, ok, err := parseEnvScalar(
+		e, key, "bool",
+		func(s string) (bool, error) { return ParseEnvBool(s, e.Parse) },
+	)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvIntCsv returns the value of the variable named by the key as a slice
+// of ints, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvIntCsv(key string, defaultValue []int64, bitSize int) (
+	[]int64, error,
+) {
+	vs, err := parseEnvCsv(
+		e, key, "[]"+intTypeName("int", bitSize),
+		func(s string) (int64, error) { return ParseEnvInt(s, bitSize, e.Parse) },
+	)
 	if err != nil {
 		return nil, err
 	}
+	if nil == vs {
+		return defaultValue, nil
+	}
 	return vs, nil
 }
 
-// GetEnvInt8Csv returns the value of the environment variable named by
-// the key as a slice of int8s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvInt8Csv(key string, defaultValue []int8) ([]int8, error) {
+// GetEnvInt8Csv returns the value of the variable named by the key as a slice
+// of int8s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt8Csv(key string, defaultValue []int8) ([]int8, error) {
 	dv, _ := SliceMapFuncE[[]int64](
 		defaultValue, func(i int8) (int64, error) { return int64(i), nil },
 	)
-	vs, err := GetEnvIntCsv(key, dv, 8)
+	vs, err := e.GetEnvIntCsv(key, dv, 8)
 	if err != nil {
 		return nil, err
 	}
@@ -248,14 +684,15 @@ func GetEnvInt8Csv(key string, defaultValue []int8) ([]int8, error) {
 	)
 }
 
-// GetEnvInt16Csv returns the value of the environment variable named by
-// the key as a slice of int16s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvInt16Csv(key string, defaultValue []int16) ([]int16, error) {
+// GetEnvInt16Csv returns the value of the variable named by the key as a
+// slice of int16s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt16Csv(key string, defaultValue []int16) (
+	[]int16, error,
+) {
 	dv, _ := SliceMapFuncE[[]int64](
 		defaultValue, func(i int16) (int64, error) { return int64(i), nil },
 	)
-	vs, err := GetEnvIntCsv(key, dv, 16)
+	vs, err := e.GetEnvIntCsv(key, dv, 16)
 	if err != nil {
 		return nil, err
 	}
@@ -264,14 +701,15 @@ func GetEnvInt16Csv(key string, defaultValue []int16) ([]int16, error) {
 	)
 }
 
-// GetEnvInt32Csv returns the value of the environment variable named by
-// the key as a slice of int32s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvInt32Csv(key string, defaultValue []int32) ([]int32, error) {
+// GetEnvInt32Csv returns the value of the variable named by the key as a
+// slice of int32s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt32Csv(key string, defaultValue []int32) (
+	[]int32, error,
+) {
 	dv, _ := SliceMapFuncE[[]int64](
 		defaultValue, func(i int32) (int64, error) { return int64(i), nil },
 	)
-	vs, err := GetEnvIntCsv(key, dv, 32)
+	vs, err := e.GetEnvIntCsv(key, dv, 32)
 	if err != nil {
 		return nil, err
 	}
@@ -280,42 +718,43 @@ func GetEnvInt32Csv(key string, defaultValue []int32) ([]int32, error) {
 	)
 }
 
-// GetEnvInt64Csv returns the value of the environment variable named by
-// the key as a slice of int64s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvInt64Csv(key string, defaultValue []int64) ([]int64, error) {
-	return GetEnvIntCsv(key, defaultValue, 64)
+// GetEnvInt64Csv returns the value of the variable named by the key as a
+// slice of int64s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt64Csv(key string, defaultValue []int64) (
+	[]int64, error,
+) {
+	return e.GetEnvIntCsv(key, defaultValue, 64)
 }
 
-// GetEnvUintCsv returns the value of the environment variable named by
-// the key as a slice of uints. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvUintCsv(key string, defaultValue []uint64, bitSize int) (
+// GetEnvUintCsv returns the value of the variable named by the key as a slice
+// of uints, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUintCsv(key string, defaultValue []uint64, bitSize int) (
 	[]uint64, error,
 ) {
-	ss := GetEnvCsv(key, nil)
-	if nil == ss {
-		return defaultValue, nil
-	}
-	vs, err := SliceMapFuncE[[]uint64](
-		ss, func(s string) (uint64, error) {
-			return strconv.ParseUint(s, 10, bitSize)
+	vs, err := parseEnvCsv(
+		e, key, "[]"+intTypeName("uint", bitSize),
+		func(s string) (uint64, error) {
+			return ParseEnvUint(s, bitSize, e.Parse)
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
+	if nil == vs {
+		return defaultValue, nil
+	}
 	return vs, nil
 }
 
-// GetEnvUint8Csv returns the value of the environment variable named by
-// the key as a slice of uint8s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvUint8Csv(key string, defaultValue []uint8) ([]uint8, error) {
+// GetEnvUint8Csv returns the value of the variable named by the key as a
+// slice of uint8s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUint8Csv(key string, defaultValue []uint8) (
+	[]uint8, error,
+) {
 	dv, _ := SliceMapFuncE[[]uint64](
 		defaultValue, func(i uint8) (uint64, error) { return uint64(i), nil },
 	)
-	vs, err := GetEnvUintCsv(key, dv, 8)
+	vs, err := e.GetEnvUintCsv(key, dv, 8)
 	if err != nil {
 		return nil, err
 	}
@@ -324,14 +763,16 @@ func GetEnvUint8Csv(key string, defaultValue []uint8) ([]uint8, error) {
 	)
 }
 
-// GetEnvUint16Csv returns the value of the environment variable named by
-// the key as a slice of uint16s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvUint16Csv(key string, defaultValue []uint16) ([]uint16, error) {
+// GetEnvUint16Csv returns the value of the variable named by the key as a
+// slice of uint16s, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvUint16Csv(key string, defaultValue []uint16) (
+	[]uint16, error,
+) {
 	dv, _ := SliceMapFuncE[[]uint64](
 		defaultValue, func(i uint16) (uint64, error) { return uint64(i), nil },
 	)
-	vs, err := GetEnvUintCsv(key, dv, 16)
+	vs, err := e.GetEnvUintCsv(key, dv, 16)
 	if err != nil {
 		return nil, err
 	}
@@ -340,14 +781,16 @@ func GetEnvUint16Csv(key string, defaultValue []uint16) ([]uint16, error) {
 	)
 }
 
-// GetEnvUint32Csv returns the value of the environment variable named by
-// the key as a slice of uint32s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvUint32Csv(key string, defaultValue []uint32) ([]uint32, error) {
+// GetEnvUint32Csv returns the value of the variable named by the key as a
+// slice of uint32s, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvUint32Csv(key string, defaultValue []uint32) (
+	[]uint32, error,
+) {
 	dv, _ := SliceMapFuncE[[]uint64](
 		defaultValue, func(i uint32) (uint64, error) { return uint64(i), nil },
 	)
-	vs, err := GetEnvUintCsv(key, dv, 32)
+	vs, err := e.GetEnvUintCsv(key, dv, 32)
 	if err != nil {
 		return nil, err
 	}
@@ -356,45 +799,44 @@ func GetEnvUint32Csv(key string, defaultValue []uint32) ([]uint32, error) {
 	)
 }
 
-// GetEnvUint64Csv returns the value of the environment variable named by
-// the key as a slice of uint64s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvUint64Csv(key string, defaultValue []uint64) ([]uint64, error) {
-	return GetEnvUintCsv(key, defaultValue, 64)
+// GetEnvUint64Csv returns the value of the variable named by the key as a
+// slice of uint64s, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvUint64Csv(key string, defaultValue []uint64) (
+	[]uint64, error,
+) {
+	return e.GetEnvUintCsv(key, defaultValue, 64)
 }
 
-// GetEnvFloatCsv returns the value of the environment variable named by
-// the key as a slice of floats. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvFloatCsv(key string, defaultValue []float64, bitSize int) (
-	[]float64, error,
-) {
-	ss := GetEnvCsv(key, nil)
-	if nil == ss {
-		return defaultValue, nil
-	}
-	vs, err := SliceMapFuncE[[]float64](
-		ss, func(s string) (float64, error) {
-			return strconv.ParseFloat(
-				s, bitSize,
-			)
-		},
+// GetEnvFloatCsv returns the value of the variable named by the key as a
+// slice of floats, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvFloatCsv(
+	key string, defaultValue []float64, bitSize int,
+) ([]float64, error) {
+	vs, err := parseEnvCsv(
+		e, key, "[]"+intTypeName("float", bitSize),
+		func(s string) (float64, error) { return strconv.ParseFloat(s, bitSize) },
 	)
 	if err != nil {
 		return nil, err
 	}
+	if nil == vs {
+		return defaultValue, nil
+	}
 	return vs, nil
 }
 
-// GetEnvFloat32Csv returns the value of the environment variable named by
-// the key as a slice of float32s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvFloat32Csv(key string, defaultValue []float32) ([]float32, error) {
+// GetEnvFloat32Csv returns the value of the variable named by the key as a
+// slice of float32s, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvFloat32Csv(key string, defaultValue []float32) (
+	[]float32, error,
+) {
 	dv, _ := SliceMapFuncE[[]float64](
 		defaultValue,
 		func(f float32) (float64, error) { return float64(f), nil },
 	)
-	vs, err := GetEnvFloatCsv(key, dv, 32)
+	vs, err := e.GetEnvFloatCsv(key, dv, 32)
 	if err != nil {
 		return nil, err
 	}
@@ -403,27 +845,27 @@ func GetEnvFloat32Csv(key string, defaultValue []float32) ([]float32, error) {
 	)
 }
 
-// GetEnvFloat64Csv returns the value of the environment variable named by
-// the key as a slice of float64s. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvFloat64Csv(key string, defaultValue []float64) ([]float64, error) {
-	return GetEnvFloatCsv(key, defaultValue, 64)
+// GetEnvFloat64Csv returns the value of the variable named by the key as a
+// slice of float64s, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvFloat64Csv(key string, defaultValue []float64) (
+	[]float64, error,
+) {
+	return e.GetEnvFloatCsv(key, defaultValue, 64)
 }
 
-// GetEnvBoolCsv returns the value of the environment variable named by
-// the key as a slice of bools. It is guaranteed to return the default
-// value if the environment variable is not found.
-func GetEnvBoolCsv(key string, defaultValue []bool) ([]bool, error) {
-	ss := GetEnvCsv(key, nil)
-	if nil == ss {
-		return defaultValue, nil
-	}
-	vs, err := SliceMapFuncE[[]bool](
-		ss,
-		func(s string) (bool, error) { return strconv.ParseBool(s) },
+// GetEnvBoolCsv returns the value of the variable named by the key as a slice
+// of bools, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvBoolCsv(key string, defaultValue []bool) ([]bool, error) {
+	vs, err := parseEnvCsv(
+		e, key, "[]bool",
+		func(s string) (bool, error) { return ParseEnvBool(s, e.Parse) },
 	)
 	if err != nil {
 		return nil, err
 	}
+	if nil == vs {
+		return defaultValue, nil
+	}
 	return vs, nil
 }
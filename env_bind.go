@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrInvalidEnvTarget    = errors.New("env target must be a non-nil pointer to struct")
+	ErrEnvInvalidSeparator = errors.New("separator must be a single character")
+)
+
+// LoadEnv populates the struct pointed to by `dst` from environment variables.
+// Fields are bound using the following struct tags:
+//
+//   - `env:"NAME"` the name of the environment variable, `env:"-"` skips the
+//     field. Fields without the tag are ignored, unless they are structs.
+//   - `default:"..."` the value used if the variable is not found or empty.
+//   - `required:"true"` returns an error if the variable is not found or empty
+//     and there is no default value.
+//   - `sep:","` the separator used to split slice values or map entries,
+//     overriding the one in `Env.Csv` or `Env.Map`. It must be a single
+//     character, `ErrEnvInvalidSeparator` is returned otherwise.
+//   - `prefix:"DB_"` the prefix prepended to all variables of a nested struct.
+//   - `desc:"..."` the description of the variable, see `Env.Declare()`.
+//   - `sensitive:"true"` the value is a secret, see `EnvVar`.
+//
+// All bound variables are declared in the `Env`.
+//
+// Field types supported by `ParseEnvValue()`, slices of them and maps of them
+// with string keys are supported.
+// Pointer fields are left `nil` if the variable is not set and has no default
+// value.
+//
+// Problems of all fields are collected and returned as `EnvErrors`.
+func LoadEnv(dst any) error {
+	return DefaultEnv.LoadEnv(dst)
+}
+
+// LoadEnv populates the struct pointed to by `dst` from variables of the `Env`.
+// See the package level `LoadEnv()` for supported struct tags.
+func (e *Env) LoadEnv(dst any) error {
+	c := e.NewChecker()
+	if err := c.LoadEnv(dst); err != nil {
+		return err
+	}
+	return c.Err()
+}
+
+// DeclareEnvStruct declares variables bound to fields of the struct pointed to
+// by `dst` in `DefaultEnv`, without reading them. See `LoadEnv()`.
+func DeclareEnvStruct(dst any) error {
+	return DefaultEnv.DeclareStruct(dst)
+}
+
+// DeclareStruct declares variables bound to fields of the struct pointed to by
+// `dst`, without reading them. It is useful to document or register flags of
+// the variables before they are loaded, see `WithFlags()`.
+func (e *Env) DeclareStruct(dst any) error {
+	rv := reflect.ValueOf(dst)
+	if reflect.Pointer != rv.Kind() || rv.IsNil() ||
+		reflect.Struct != rv.Elem().Kind() {
+		return ErrInvalidEnvTarget
+	}
+	return declareEnvStruct(e, rv.Elem().Type(), "")
+}
+
+func loadEnv(c *EnvChecker, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if reflect.Pointer != rv.Kind() || rv.IsNil() ||
+		reflect.Struct != rv.Elem().Kind() {
+		return ErrInvalidEnvTarget
+	}
+	loadEnvStruct(c, rv.Elem(), "")
+	return nil
+}
+
+func declareEnvStruct(e *Env, rt reflect.Type, prefix string) error {
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, tagged := field.Tag.Lookup("env")
+		if "-" == name {
+			continue
+		}
+		if tagged {
+			if _, err := envFieldSeparator(field.Tag); err != nil {
+				return fmt.Errorf("%s: %w", prefix+name, err)
+			}
+			e.Declare(envFieldVar(field, prefix+name))
+			continue
+		}
+		ft := field.Type
+		if reflect.Pointer == ft.Kind() {
+			ft = ft.Elem()
+		}
+		if reflect.Struct != ft.Kind() {
+			continue
+		}
+		err := declareEnvStruct(e, ft, prefix+field.Tag.Get("prefix"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envFieldSeparator returns the rune of the `sep` tag, or 0 if there is none.
+func envFieldSeparator(tag reflect.StructTag) (rune, error) {
+	sep := []rune(tag.Get("sep"))
+	switch len(sep) {
+	case 0:
+		return 0, nil
+	case 1:
+		return sep[0], nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrEnvInvalidSeparator, string(sep))
+}
+
+func envFieldVar(field reflect.StructField, key string) EnvVar {
+	return EnvVar{
+		Name:        key,
+		Type:        field.Type.String(),
+		Default:     field.Tag.Get("default"),
+		Description: field.Tag.Get("desc"),
+		Required:    "true" == field.Tag.Get("required"),
+		Sensitive:   "true" == field.Tag.Get("sensitive"),
+	}
+}
+
+func loadEnvStruct(c *EnvChecker, rv reflect.Value, prefix string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, tagged := field.Tag.Lookup("env")
+		if "-" == name {
+			continue
+		}
+		fv := rv.Field(i)
+		if !tagged {
+			loadEnvNested(c, fv, prefix+field.Tag.Get("prefix"))
+			continue
+		}
+		loadEnvField(c, fv, field, prefix+name)
+	}
+}
+
+// loadEnvNested recurses into struct and pointer to struct fields, anything
+// else is left untouched.
+func loadEnvNested(c *EnvChecker, fv reflect.Value, prefix string) {
+	ft := fv.Type()
+	if reflect.Struct == ft.Kind() {
+		loadEnvStruct(c, fv, prefix)
+		return
+	}
+	if reflect.Pointer != ft.Kind() || reflect.Struct != ft.Elem().Kind() {
+		return
+	}
+	if fv.IsNil() {
+		fv.Set(reflect.New(ft.Elem()))
+	}
+	loadEnvStruct(c, fv.Elem(), prefix)
+}
+
+func loadEnvField(
+	c *EnvChecker, fv reflect.Value, field reflect.StructField, key string,
+) {
+	c.env.Declare(envFieldVar(field, key))
+	sep, err := envFieldSeparator(field.Tag)
+	if err != nil {
+		c.check(key, "", field.Type.String(), err)
+		return
+	}
+	val, _, err := c.env.Lookup(key)
+	if err != nil {
+		c.check(key, val, field.Type.String(), err)
+		return
+	}
+	if "" == val {
+		val = field.Tag.Get("default")
+	}
+	if "" == val {
+		if "true" == field.Tag.Get("required") {
+			c.check(key, val, field.Type.String(), ErrEnvMissing)
+		}
+		return
+	}
+	c.check(key, val, field.Type.String(), setEnvValue(c, fv, val, sep))
+}
+
+func setEnvValue(c *EnvChecker, fv reflect.Value, val string, sep rune) error {
+	ft := fv.Type()
+	if reflect.Map == ft.Kind() && reflect.String == ft.Key().Kind() &&
+		!isEnvScalar(ft) {
+		return setEnvMap(c, fv, val, sep)
+	}
+	if reflect.Slice != ft.Kind() || isEnvScalar(ft) {
+		rv, err := parseEnvValue(val, ft, c.env.Parse)
+		if err != nil {
+			return err
+		}
+		fv.Set(rv)
+		return nil
+	}
+	opts := c.env.Csv
+	if 0 != sep {
+		opts.Separator = sep
+	}
+	items, err := ParseCsv(val, opts)
+	if err != nil {
+		return err
+	}
+	sv := reflect.MakeSlice(ft, len(items), len(items))
+	for i, item := range items {
+		rv, err := parseEnvValue(item, ft.Elem(), c.env.Parse)
+		if err != nil {
+			return &envItemError{i, err}
+		}
+		sv.Index(i).Set(rv)
+	}
+	fv.Set(sv)
+	return nil
+}
+
+func setEnvMap(c *EnvChecker, fv reflect.Value, val string, sep rune) error {
+	opts := c.env.Map
+	if 0 != sep {
+		opts.Entries.Separator = sep
+	}
+	m, err := ParseEnvMap(val, opts)
+	if err != nil {
+		return err
+	}
+	ft := fv.Type()
+	mv := reflect.MakeMapWithSize(ft, len(m))
+	for k, s := range m {
+		rv, err := parseEnvValue(s, ft.Elem(), c.env.Parse)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+		mv.SetMapIndex(reflect.ValueOf(k).Convert(ft.Key()), rv)
+	}
+	fv.Set(mv)
+	return nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEnvDb struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port uint16 `env:"PORT" default:"5432"`
+}
+
+type testEnvConfig struct {
+	Name     string         `env:"TEST_BIND_NAME" required:"true"`
+	Debug    bool           `env:"TEST_BIND_DEBUG"`
+	Threads  int8           `env:"TEST_BIND_THREADS" default:"4"`
+	Memory   uint32         `env:"TEST_BIND_MEMORY"`
+	Ratio    float64        `env:"TEST_BIND_RATIO"`
+	Tags     []string       `env:"TEST_BIND_TAGS"`
+	Ports    []uint16       `env:"TEST_BIND_PORTS" sep:";"`
+	Weights  map[string]int `env:"TEST_BIND_WEIGHTS" sep:";"`
+	Timeout  *int64         `env:"TEST_BIND_TIMEOUT"`
+	Limit    *float32       `env:"TEST_BIND_LIMIT" default:"1.5"`
+	Db       testEnvDb      `prefix:"TEST_BIND_DB_"`
+	Replica  *testEnvDb     `prefix:"TEST_BIND_REPLICA_"`
+	Ignored  string         `env:"-"`
+	NotBound string
+	private  string `env:"TEST_BIND_NAME"`
+}
+
+func Test_LoadEnv(t *testing.T) {
+	t.Setenv("TEST_BIND_NAME", "app")
+	t.Setenv("TEST_BIND_DEBUG", "true")
+	t.Setenv("TEST_BIND_THREADS", "")
+	t.Setenv("TEST_BIND_MEMORY", "65536")
+	t.Setenv("TEST_BIND_RATIO", "0.5")
+	t.Setenv("TEST_BIND_TAGS", "a,,b, c")
+	t.Setenv("TEST_BIND_PORTS", "80;443")
+	t.Setenv("TEST_BIND_WEIGHTS", "a=1;b=2")
+	t.Setenv("TEST_BIND_DB_HOST", "db")
+	t.Setenv("TEST_BIND_REPLICA_PORT", "5433")
+	var cfg testEnvConfig
+	require.Nil(t, LoadEnv(&cfg))
+	require.Equal(t, "app", cfg.Name)
+	require.True(t, cfg.Debug)
+	require.Equal(t, int8(4), cfg.Threads)
+	require.Equal(t, uint32(65536), cfg.Memory)
+	require.Equal(t, 0.5, cfg.Ratio)
+	require.Equal(t, []string{"a", "b", "c"}, cfg.Tags)
+	require.Equal(t, []uint16{80, 443}, cfg.Ports)
+	require.Equal(t, map[string]int{"a": 1, "b": 2}, cfg.Weights)
+	require.Nil(t, cfg.Timeout)
+	require.Equal(t, float32(1.5), *cfg.Limit)
+	require.Equal(t, testEnvDb{"db", 5432}, cfg.Db)
+	require.Equal(t, &testEnvDb{"localhost", 5433}, cfg.Replica)
+	require.Empty(t, cfg.Ignored)
+	require.Empty(t, cfg.NotBound)
+	require.Empty(t, cfg.private)
+}
+
+func Test_LoadEnv_sets_pointer_fields(t *testing.T) {
+	t.Setenv("TEST_BIND_NAME", "app")
+	t.Setenv("TEST_BIND_TIMEOUT", "30")
+	var cfg testEnvConfig
+	require.Nil(t, LoadEnv(&cfg))
+	require.Equal(t, int64(30), *cfg.Timeout)
+}
+
+func Test_LoadEnv_returns_error_if_required_missing(t *testing.T) {
+	t.Setenv("TEST_BIND_NAME", "")
+	var cfg testEnvConfig
+	require.ErrorContains(t, LoadEnv(&cfg), "TEST_BIND_NAME")
+}
+
+func Test_LoadEnv_returns_error_if_invalid_value(t *testing.T) {
+	t.Setenv("TEST_BIND_NAME", "app")
+	t.Setenv("TEST_BIND_THREADS", "1234")
+	var cfg testEnvConfig
+	require.ErrorContains(t, LoadEnv(&cfg), "TEST_BIND_THREADS")
+}
+
+func Test_LoadEnv_returns_error_if_invalid_slice_item(t *testing.T) {
+	t.Setenv("TEST_BIND_NAME", "app")
+	t.Setenv("TEST_BIND_PORTS", "80;abc")
+	var cfg testEnvConfig
+	require.ErrorContains(t, LoadEnv(&cfg), "TEST_BIND_PORTS")
+}
+
+func Test_LoadEnv_returns_error_if_unsupported_type(t *testing.T) {
+	t.Setenv("TEST_BIND_UNSUPPORTED", "1")
+	var cfg struct {
+		C chan int `env:"TEST_BIND_UNSUPPORTED"`
+	}
+	require.ErrorIs(t, LoadEnv(&cfg), ErrEnvUnsupportedType)
+}
+
+func Test_LoadEnv_returns_error_if_separator_not_single_character(
+	t *testing.T,
+) {
+	env := NewEnv(MapEnv{"TEST_BIND_SEP": "a::b:c"})
+	var cfg struct {
+		Nested struct {
+			Items []string `env:"TEST_BIND_SEP" sep:"::"`
+		}
+	}
+	err := env.LoadEnv(&cfg)
+	require.ErrorIs(t, err, ErrEnvInvalidSeparator)
+	require.ErrorContains(t, err, "TEST_BIND_SEP")
+	require.Nil(t, cfg.Nested.Items)
+	err = NewEnv(MapEnv{}).DeclareStruct(&cfg)
+	require.ErrorIs(t, err, ErrEnvInvalidSeparator)
+	require.EqualError(
+		t, err, `TEST_BIND_SEP: separator must be a single character: "::"`,
+	)
+}
+
+func Test_LoadEnv_returns_error_if_invalid_target(t *testing.T) {
+	var cfg testEnvConfig
+	require.ErrorIs(t, LoadEnv(cfg), ErrInvalidEnvTarget)
+	require.ErrorIs(t, LoadEnv((*testEnvConfig)(nil)), ErrInvalidEnvTarget)
+	s := "string"
+	require.ErrorIs(t, LoadEnv(&s), ErrInvalidEnvTarget)
+}
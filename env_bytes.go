@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BytesEncoding is the text encoding of binary values.
+type BytesEncoding int
+
+const (
+	// BytesHex is hexadecimal encoding, case-insensitive
+	BytesHex BytesEncoding = iota
+	// BytesBase64 is the standard padded base64 encoding
+	BytesBase64
+	// BytesBase64Raw is the standard base64 encoding without padding
+	BytesBase64Raw
+	// BytesBase64Url is the URL-safe padded base64 encoding
+	BytesBase64Url
+	// BytesBase64RawUrl is the URL-safe base64 encoding without padding
+	BytesBase64RawUrl
+)
+
+var (
+	ErrUnknownBytesEncoding = errors.New("unknown bytes encoding")
+	ErrInvalidBytesEncoding = errors.New("invalid bytes encoding")
+)
+
+// redactedEnvValue replaces values of secrets in errors.
+const redactedEnvValue = "***"
+
+// BytesOptions controls how binary values are decoded and checked.
+type BytesOptions struct {
+	// Encoding of the value
+	Encoding BytesEncoding
+	// Required number of decoded bytes, any length is allowed if it is zero
+	Len int
+	// Minimum number of decoded bytes
+	MinLen int
+}
+
+func (e BytesEncoding) String() string {
+	switch e {
+	case BytesHex:
+		return "hex"
+	case BytesBase64:
+		return "base64"
+	case BytesBase64Raw:
+		return "raw base64"
+	case BytesBase64Url:
+		return "url base64"
+	case BytesBase64RawUrl:
+		return "raw url base64"
+	}
+	return "unknown"
+}
+
+// DecodeBytes decodes the string using the encoding. Leading and trailing white
+// spaces are ignored.
+func DecodeBytes(s string, enc BytesEncoding) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	switch enc {
+	case BytesHex:
+		return hex.DecodeString(s)
+	case BytesBase64:
+		return base64.StdEncoding.DecodeString(s)
+	case BytesBase64Raw:
+		return base64.RawStdEncoding.DecodeString(s)
+	case BytesBase64Url:
+		return base64.URLEncoding.DecodeString(s)
+	case BytesBase64RawUrl:
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return nil, ErrUnknownBytesEncoding
+}
+
+// GetEnvBytes returns the value of the environment variable named by the key
+// decoded as specified by `opts`. It is guaranteed to return the default value
+// if the environment variable is not found or empty. Decoded values of
+// invalid length are rejected with `ErrEnvLength`. Since binary values are
+// usually secrets, values are redacted in errors, and malformed values are
+// reported by `ErrInvalidBytesEncoding` without details.
+func GetEnvBytes(key string, defaultValue []byte, opts BytesOptions) (
+	[]byte, error,
+) {
+	return DefaultEnv.GetEnvBytes(key, defaultValue, opts)
+}
+
+// MustGetEnvBytes returns the value of the environment variable named by the
+// key decoded as specified by `opts`. It panics if the environment variable is
+// not found, empty, or invalid.
+func MustGetEnvBytes(key string, opts BytesOptions) []byte {
+	return DefaultEnv.MustGetEnvBytes(key, opts)
+}
+
+// GetEnvBytes returns the value of the variable named by the key decoded as
+// specified by `opts`, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvBytes(
+	key string, defaultValue []byte, opts BytesOptions,
+) ([]byte, error) {
+	ret, ok, err := e.parseEnvBytes(key, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// MustGetEnvBytes returns the value of the variable named by the key decoded
+// as specified by `opts`. It panics if the variable is not found, empty, or
+// invalid.
+func (e *Env) MustGetEnvBytes(key string, opts BytesOptions) []byte {
+	ret, ok, err := e.parseEnvBytes(key, opts)
+	PanicIfError(err)
+	if !ok {
+		panic(ErrEnvMissing.Error() + ": " + e.Prefix + key)
+	}
+	return ret
+}
+
+func (e *Env) parseEnvBytes(key string, opts BytesOptions) (
+	[]byte, bool, error,
+) {
+	ret, ok, err := parseEnvScalar(
+		e, key, "[]byte", func(s string) ([]byte, error) {
+			b, err := DecodeBytes(s, opts.Encoding)
+			if errors.Is(err, ErrUnknownBytesEncoding) {
+				return nil, err
+			}
+			if err != nil {
+				// decoding errors may reveal bytes of the secret or their offset
+				return nil, fmt.Errorf(
+					"%w: %s", ErrInvalidBytesEncoding, opts.Encoding,
+				)
+			}
+			if opts.Len > 0 && len(b) != opts.Len {
+				return nil, fmt.Errorf(
+					"%w: %d bytes, want %d", ErrEnvLength, len(b), opts.Len,
+				)
+			}
+			if len(b) < opts.MinLen {
+				return nil, fmt.Errorf(
+					"%w: %d bytes, want at least %d", ErrEnvLength, len(b),
+					opts.MinLen,
+				)
+			}
+			return b, nil
+		},
+	)
+	var ee *EnvError
+	if errors.As(err, &ee) {
+		ee.Value = redactedEnvValue
+	}
+	return ret, ok, err
+}
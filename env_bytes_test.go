@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeBytes(t *testing.T) {
+	want := []byte{0xfb, 0xff, 0x01}
+	tests := []struct {
+		name    string
+		input   string
+		enc     BytesEncoding
+		want    []byte
+		wantErr bool
+	}{
+		{"hex", "fbff01", BytesHex, want, false},
+		{"hex upper", " FBFF01\n", BytesHex, want, false},
+		{"hex invalid", "fbfg", BytesHex, nil, true},
+		{"base64", "+/8B", BytesBase64, want, false},
+		{"base64 padded", "+/8=", BytesBase64, want[:2], false},
+		{"base64 missing padding", "+/8", BytesBase64, nil, true},
+		{"raw base64", "+/8", BytesBase64Raw, want[:2], false},
+		{"raw base64 padded", "+/8=", BytesBase64Raw, nil, true},
+		{"url base64", "-_8=", BytesBase64Url, want[:2], false},
+		{"url base64 std chars", "+/8=", BytesBase64Url, nil, true},
+		{"raw url base64", "-_8", BytesBase64RawUrl, want[:2], false},
+		{"unknown", "00", BytesEncoding(99), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := DecodeBytes(tt.input, tt.enc)
+				if tt.wantErr {
+					require.NotNil(t, err)
+					return
+				}
+				require.Nil(t, err)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+	_, err := DecodeBytes("00", BytesEncoding(99))
+	require.ErrorIs(t, err, ErrUnknownBytesEncoding)
+}
+
+func Test_BytesEncoding_String(t *testing.T) {
+	require.Equal(t, "hex", BytesHex.String())
+	require.Equal(t, "base64", BytesBase64.String())
+	require.Equal(t, "raw base64", BytesBase64Raw.String())
+	require.Equal(t, "url base64", BytesBase64Url.String())
+	require.Equal(t, "raw url base64", BytesBase64RawUrl.String())
+	require.Equal(t, "unknown", BytesEncoding(99).String())
+}
+
+func Test_GetEnvBytes(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	t.Setenv("TEST_ENV_BYTES_HEX", hex.EncodeToString(key))
+	t.Setenv("TEST_ENV_BYTES_B64", base64.StdEncoding.EncodeToString(key))
+	t.Setenv("TEST_ENV_BYTES_INVALID", "not hex")
+	got, err := GetEnvBytes("TEST_ENV_BYTES_HEX", nil, BytesOptions{Len: 32})
+	require.Nil(t, err)
+	require.Equal(t, key, got)
+	got, err = GetEnvBytes(
+		"TEST_ENV_BYTES_B64", nil,
+		BytesOptions{Encoding: BytesBase64, MinLen: 16},
+	)
+	require.Nil(t, err)
+	require.Equal(t, key, got)
+	def := []byte{1}
+	got, err = GetEnvBytes("TEST_ENV_BYTES_MISSING", def, BytesOptions{Len: 32})
+	require.Nil(t, err)
+	require.Equal(t, def, got)
+	_, err = GetEnvBytes("TEST_ENV_BYTES_HEX", nil, BytesOptions{Len: 16})
+	require.ErrorIs(t, err, ErrEnvLength)
+	require.EqualError(
+		t, err, `invalid environment variable TEST_ENV_BYTES_HEX ([]byte) `+
+			`value "***": invalid length: 32 bytes, want 16`,
+	)
+	_, err = GetEnvBytes("TEST_ENV_BYTES_HEX", nil, BytesOptions{MinLen: 64})
+	require.ErrorIs(t, err, ErrEnvLength)
+	require.ErrorContains(t, err, "32 bytes, want at least 64")
+	_, err = GetEnvBytes("TEST_ENV_BYTES_INVALID", nil, BytesOptions{})
+	var ee *EnvError
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "TEST_ENV_BYTES_INVALID", ee.Key)
+	require.Equal(t, "***", ee.Value)
+	require.ErrorIs(t, err, ErrInvalidBytesEncoding)
+	require.EqualError(
+		t, err, `invalid environment variable TEST_ENV_BYTES_INVALID ([]byte) `+
+			`value "***": invalid bytes encoding: hex`,
+	)
+}
+
+func Test_MustGetEnvBytes(t *testing.T) {
+	t.Setenv("TEST_ENV_BYTES_HEX", "0102")
+	require.Equal(
+		t, []byte{1, 2}, MustGetEnvBytes("TEST_ENV_BYTES_HEX", BytesOptions{}),
+	)
+	require.PanicsWithValue(
+		t, "missing environment variable: TEST_ENV_BYTES_MISSING", func() {
+			MustGetEnvBytes("TEST_ENV_BYTES_MISSING", BytesOptions{})
+		},
+	)
+	require.Panics(
+		t, func() {
+			MustGetEnvBytes("TEST_ENV_BYTES_HEX", BytesOptions{Len: 32})
+		},
+	)
+}
@@ -0,0 +1,381 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrEnvMissing = errors.New("missing environment variable")
+	ErrEnvEmpty   = errors.New("empty environment variable")
+)
+
+// EnvError describes a problem with a single environment variable. It is
+// returned by all getters returning errors. Causes of type `*strconv.NumError`
+// are kept, but only their reason is part of the message, since the value is
+// already part of it.
+type EnvError struct {
+	// Name of the environment variable
+	Key string
+	// Raw value of the environment variable
+	Value string
+	// Index of the failing item of list values, or -1
+	Index int
+	// Expected type of the value
+	Type string
+	// The reason of the problem
+	Err error
+	// Suggestion to fix the problem, such as a similar variable name
+	Hint string
+}
+
+func (e *EnvError) Error() string {
+	if errors.Is(e.Err, ErrEnvMissing) || errors.Is(e.Err, ErrEnvEmpty) ||
+		errors.Is(e.Err, ErrEnvUnknown) {
+		return e.Err.Error() + ": " + e.Key + e.hint()
+	}
+	var sb strings.Builder
+	sb.WriteString("invalid environment variable ")
+	sb.WriteString(e.Key)
+	if "" != e.Type {
+		sb.WriteString(" (")
+		sb.WriteString(e.Type)
+		sb.WriteString(")")
+	}
+	// JSON errors locate the problem by offset, values are usually long
+	var je *EnvJsonError
+	if !errors.As(e.Err, &je) {
+		sb.WriteString(" value ")
+		sb.WriteString(strconv.Quote(e.Value))
+	}
+	if e.Index >= 0 {
+		sb.WriteString(": item ")
+		sb.WriteString(strconv.Itoa(e.Index))
+	}
+	if ne, ok := e.Err.(*strconv.NumError); ok {
+		sb.WriteString(": ")
+		sb.WriteString(ne.Err.Error())
+	} else if nil != e.Err {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+	sb.WriteString(e.hint())
+	return sb.String()
+}
+
+func (e *EnvError) hint() string {
+	if "" == e.Hint {
+		return ""
+	}
+	return " (" + e.Hint + ")"
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+// EnvErrors is a list of environment variable problems reported as a single
+// error.
+type EnvErrors []*EnvError
+
+func (e EnvErrors) Error() string {
+	if 1 == len(e) {
+		return e[0].Error()
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d environment variable problems:", len(e)))
+	for _, err := range e {
+		sb.WriteString("\n  ")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+func (e EnvErrors) Unwrap() []error {
+	return SliceMapFunc[[]error](e, func(err *EnvError) error { return err })
+}
+
+// EnvChecker reads environment variables and collects all problems instead of
+// failing on the first one. Values of problematic variables are the defaults
+// or zero values. Call `Err()` after all variables have been read.
+type EnvChecker struct {
+	env  *Env
+	errs EnvErrors
+}
+
+// NewEnvChecker returns a new checker reading from `DefaultEnv`.
+func NewEnvChecker() *EnvChecker {
+	return DefaultEnv.NewChecker()
+}
+
+// NewChecker returns a new checker reading from the `Env`.
+func (e *Env) NewChecker() *EnvChecker {
+	return &EnvChecker{env: e}
+}
+
+// Err returns all collected problems as `EnvErrors`, or `nil` if there is
+// none.
+func (c *EnvChecker) Err() error {
+	if 0 == len(c.errs) {
+		return nil
+	}
+	return c.errs
+}
+
+// Errors returns all collected problems.
+func (c *EnvChecker) Errors() EnvErrors {
+	return c.errs
+}
+
+// Check records the error returned while reading the environment variable
+// named by the key. It does nothing if `err` is `nil`.
+func (c *EnvChecker) Check(key, typ string, err error) {
+	if nil == err {
+		return
+	}
+	c.check(key, c.env.peek(key), typ, err)
+}
+
+func (c *EnvChecker) check(key, val, typ string, err error) {
+	if nil == err {
+		return
+	}
+	var ee *EnvError
+	if errors.As(err, &ee) {
+		c.errs = append(c.errs, ee)
+		return
+	}
+	c.errs = append(c.errs, c.env.envError(key, val, typ, err))
+}
+
+// Require returns the value of the environment variable named by the key.
+// It records a problem if the environment variable is not found.
+func (c *EnvChecker) Require(key string) string {
+	val, found, err := c.env.Lookup(key)
+	if err != nil {
+		c.Check(key, "string", err)
+	} else if !found {
+		c.Check(key, "string", ErrEnvMissing)
+	}
+	return val
+}
+
+// RequireNE returns the value of the environment variable named by the key.
+// It records a problem if the environment variable is not found or empty.
+func (c *EnvChecker) RequireNE(key string) string {
+	val, found, err := c.env.Lookup(key)
+	if err != nil {
+		c.Check(key, "string", err)
+	} else if !found {
+		c.Check(key, "string", ErrEnvMissing)
+	} else if "" == val {
+		c.Check(key, "string", ErrEnvEmpty)
+	}
+	return val
+}
+
+// Int returns the value of the environment variable named by the key as an
+// int. It records a problem if the value is not a valid integer.
+func (c *EnvChecker) Int(key string, defaultValue int64, bitSize int) int64 {
+	ret, err := c.env.GetEnvInt(key, defaultValue, bitSize)
+	c.Check(key, intTypeName("int", bitSize), err)
+	return ret
+}
+
+// Uint returns the value of the environment variable named by the key as an
+// uint. It records a problem if the value is not a valid unsigned integer.
+func (c *EnvChecker) Uint(key string, defaultValue uint64, bitSize int) uint64 {
+	ret, err := c.env.GetEnvUint(key, defaultValue, bitSize)
+	c.Check(key, intTypeName("uint", bitSize), err)
+	return ret
+}
+
+// Float returns the value of the environment variable named by the key as a
+// float. It records a problem if the value is not a valid float.
+func (c *EnvChecker) Float(
+	key string, defaultValue float64, bitSize int,
+) float64 {
+	ret, err := c.env.GetEnvFloat(key, defaultValue, bitSize)
+	c.Check(key, intTypeName("float", bitSize), err)
+	return ret
+}
+
+// Bool returns the value of the environment variable named by the key as a
+// bool. It records a problem if the value is not a valid boolean.
+func (c *EnvChecker) Bool(key string, defaultValue bool) bool {
+	ret, err := c.env.GetEnvBool(key, defaultValue)
+	c.Check(key, "bool", err)
+	return ret
+}
+
+// IntCsv returns the value of the environment variable named by the key as a
+// slice of ints. It records a problem if any item is not a valid integer.
+func (c *EnvChecker) IntCsv(
+	key string, defaultValue []int64, bitSize int,
+) []int64 {
+	ret, err := c.env.GetEnvIntCsv(key, defaultValue, bitSize)
+	c.Check(key, "[]"+intTypeName("int", bitSize), err)
+	return ret
+}
+
+// UintCsv returns the value of the environment variable named by the key as a
+// slice of uints. It records a problem if any item is not a valid unsigned
+// integer.
+func (c *EnvChecker) UintCsv(
+	key string, defaultValue []uint64, bitSize int,
+) []uint64 {
+	ret, err := c.env.GetEnvUintCsv(key, defaultValue, bitSize)
+	c.Check(key, "[]"+intTypeName("uint", bitSize), err)
+	return ret
+}
+
+// FloatCsv returns the value of the environment variable named by the key as
+// a slice of floats. It records a problem if any item is not a valid float.
+func (c *EnvChecker) FloatCsv(
+	key string, defaultValue []float64, bitSize int,
+) []float64 {
+	ret, err := c.env.GetEnvFloatCsv(key, defaultValue, bitSize)
+	c.Check(key, "[]"+intTypeName("float", bitSize), err)
+	return ret
+}
+
+// BoolCsv returns the value of the environment variable named by the key as a
+// slice of bools. It records a problem if any item is not a valid boolean.
+func (c *EnvChecker) BoolCsv(key string, defaultValue []bool) []bool {
+	ret, err := c.env.GetEnvBoolCsv(key, defaultValue)
+	c.Check(key, "[]bool", err)
+	return ret
+}
+
+// LoadEnv populates the struct pointed to by `dst` the same way as
+// `Env.LoadEnv()`, recording problems of all fields. It only
+// returns an error if `dst` is not a non-nil pointer to struct.
+func (c *EnvChecker) LoadEnv(dst any) error {
+	return loadEnv(c, dst)
+}
+
+// envItemError is the error of an item of a list value.
+type envItemError struct {
+	index int
+	err   error
+}
+
+func (e *envItemError) Error() string {
+	return "item " + strconv.Itoa(e.index) + ": " + e.err.Error()
+}
+
+func (e *envItemError) Unwrap() error {
+	return e.err
+}
+
+// newEnvError returns an `EnvError` of the variable, taking the index from
+// `*envItemError`.
+func newEnvError(key, val, typ string, err error) *EnvError {
+	ee := &EnvError{Key: key, Value: val, Index: -1, Type: typ, Err: err}
+	if ie, ok := ee.Err.(*envItemError); ok {
+		ee.Index, ee.Err = ie.index, ie.err
+	}
+	return ee
+}
+
+// envError returns an `EnvError` of the variable named by the key, under the
+// scope of the `Env`, redacted if the value holds decrypted data.
+func (e *Env) envError(key, val, typ string, err error) *EnvError {
+	ee := newEnvError(e.Prefix+key, val, typ, err)
+	if e.decrypted(key) {
+		ee.redact()
+	}
+	return ee
+}
+
+// decrypted reports whether the value of the variable named by the key, under
+// the scope, holds decrypted data. It is only called on errors, the variable
+// is looked up again without being recorded in `Usage`. Values that cannot be
+// looked up are considered decrypted.
+func (e *Env) decrypted(key string) bool {
+	if nil == e.MasterKey {
+		return false
+	}
+	src := *e
+	src.Usage = nil
+	_, _, decrypted, err := src.lookupScoped(key)
+	return decrypted || err != nil
+}
+
+// redact hides the value and the message of the cause, which may quote the
+// value, such as errors of `url.Parse()`.
+func (e *EnvError) redact() {
+	e.Value = redactedEnvValue
+	if _, ok := e.Err.(*envRedactedError); nil != e.Err && !ok {
+		e.Err = &envRedactedError{e.Err}
+	}
+}
+
+// envRedactedError hides the message of the cause of a redacted `EnvError`.
+// The cause is still available to `errors.Is()` and `errors.As()`.
+type envRedactedError struct {
+	err error
+}
+
+func (e *envRedactedError) Error() string {
+	var ne *strconv.NumError
+	if errors.As(e.err, &ne) {
+		return ne.Err.Error()
+	}
+	return "details redacted"
+}
+
+func (e *envRedactedError) Unwrap() error {
+	return e.err
+}
+
+// parseEnvScalar parses the value of the variable named by the key using the
+// function. The returned boolean is `false` if the variable is not found or
+// empty. Parse errors are reported as `*EnvError`.
+func parseEnvScalar[T any](
+	e *Env, key, typ string, fn func(string) (T, error),
+) (T, bool, error) {
+	var zero T
+	val, err := e.value(key)
+	if err != nil || "" == val {
+		return zero, false, err
+	}
+	ret, err := fn(val)
+	if err != nil {
+		return zero, false, e.envError(key, val, typ, err)
+	}
+	return ret, true, nil
+}
+
+// parseEnvCsv splits the value of the variable named by the key as specified
+// by `Env.Csv`, and parses every item using the function. It returns `nil` if
+// the variable is not found or empty. Parse errors are reported as `*EnvError`
+// with the index of the failing item.
+func parseEnvCsv[T any](
+	e *Env, key, typ string, fn func(string) (T, error),
+) ([]T, error) {
+	val, err := e.value(key)
+	if err != nil || "" == val {
+		return nil, err
+	}
+	ss, err := ParseCsv(val, e.Csv)
+	if err != nil {
+		return nil, e.envError(key, val, typ, err)
+	}
+	vs := make([]T, len(ss))
+	for i, s := range ss {
+		if vs[i], err = fn(s); err != nil {
+			return nil, e.envError(key, val, typ, &envItemError{i, err})
+		}
+	}
+	return vs, nil
+}
+
+func intTypeName(prefix string, bitSize int) string {
+	if 0 == bitSize {
+		return prefix
+	}
+	return prefix + strconv.Itoa(bitSize)
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvChecker_collects_all_problems(t *testing.T) {
+	t.Setenv("TEST_CHECK_EMPTY", "")
+	t.Setenv("TEST_CHECK_INT", "abc")
+	t.Setenv("TEST_CHECK_UINT", "-1")
+	t.Setenv("TEST_CHECK_FLOAT", "1.5")
+	t.Setenv("TEST_CHECK_BOOL", "yes")
+	t.Setenv("TEST_CHECK_INT_CSV", "1,300")
+	c := NewEnvChecker()
+	require.Empty(t, c.Require("TEST_CHECK_MISSING"))
+	require.Empty(t, c.RequireNE("TEST_CHECK_EMPTY"))
+	require.Equal(t, int64(0), c.Int("TEST_CHECK_INT", 1, 8))
+	require.Equal(t, uint64(0), c.Uint("TEST_CHECK_UINT", 1, 0))
+	require.Equal(t, 1.5, c.Float("TEST_CHECK_FLOAT", 2, 64))
+	require.False(t, c.Bool("TEST_CHECK_BOOL", true))
+	require.Nil(t, c.IntCsv("TEST_CHECK_INT_CSV", nil, 8))
+	err := c.Err()
+	require.Len(t, c.Errors(), 6)
+	require.ErrorIs(t, err, ErrEnvMissing)
+	require.ErrorIs(t, err, ErrEnvEmpty)
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	require.ErrorIs(t, err, strconv.ErrRange)
+	require.Equal(
+		t, `6 environment variable problems:
+  missing environment variable: TEST_CHECK_MISSING
+  empty environment variable: TEST_CHECK_EMPTY
+  invalid environment variable TEST_CHECK_INT (int8) value "abc": invalid syntax
+  invalid environment variable TEST_CHECK_UINT (uint) value "-1": invalid syntax
+  invalid environment variable TEST_CHECK_BOOL (bool) value "yes": invalid syntax
+  invalid environment variable TEST_CHECK_INT_CSV ([]int8) value "1,300": item 1: value out of range`,
+		err.Error(),
+	)
+	var ee *EnvError
+	require.True(t, errors.As(err, &ee))
+	require.Equal(t, "TEST_CHECK_MISSING", ee.Key)
+}
+
+func Test_EnvChecker_returns_nil_if_no_problem(t *testing.T) {
+	t.Setenv("TEST_CHECK_STR", "")
+	t.Setenv("TEST_CHECK_CSV", "1,2")
+	c := NewEnvChecker()
+	require.Empty(t, c.Require("TEST_CHECK_STR"))
+	require.Equal(t, []uint64{1, 2}, c.UintCsv("TEST_CHECK_CSV", nil, 8))
+	require.Equal(t, []float64{1, 2}, c.FloatCsv("TEST_CHECK_CSV", nil, 64))
+	require.Equal(t, []bool{true}, c.BoolCsv("TEST_CHECK_MISSING", []bool{true}))
+	require.Nil(t, c.Err())
+	require.Empty(t, c.Errors())
+}
+
+func Test_EnvChecker_single_problem(t *testing.T) {
+	c := NewEnvChecker()
+	c.Check("TEST_CHECK_MISSING", "", errors.New("some error"))
+	require.Equal(
+		t, `invalid environment variable TEST_CHECK_MISSING value "": some error`,
+		c.Err().Error(),
+	)
+}
+
+func Test_EnvChecker_Check_reports_value_read_by_getter(t *testing.T) {
+	env := NewEnv(MapEnv{"BASE": "a", "NAME": "${BASE}b"})
+	env.Expand = true
+	c := env.NewChecker()
+	c.Check("NAME", "string", errors.New("some error"))
+	require.EqualError(
+		t, c.Err(),
+		`invalid environment variable NAME (string) value "ab": some error`,
+	)
+}
+
+func Test_EnvChecker_LoadEnv(t *testing.T) {
+	t.Setenv("TEST_BIND_NAME", "")
+	t.Setenv("TEST_BIND_THREADS", "1234")
+	t.Setenv("TEST_BIND_PORTS", "80;abc")
+	c := NewEnvChecker()
+	var cfg testEnvConfig
+	require.Nil(t, c.LoadEnv(&cfg))
+	require.Len(t, c.Errors(), 3)
+	require.Equal(t, "TEST_BIND_NAME", c.Errors()[0].Key)
+	require.Equal(t, "int8", c.Errors()[1].Type)
+	require.Equal(t, "80;abc", c.Errors()[2].Value)
+	require.ErrorIs(t, c.LoadEnv(cfg), ErrInvalidEnvTarget)
+}
+
+func Test_getters_return_EnvError(t *testing.T) {
+	env := NewEnv(
+		MapEnv{
+			"INT":   "abc",
+			"UINT":  "-1",
+			"FLOAT": "x",
+			"BOOL":  "yes",
+			"INTS":  "1,abc",
+			"UINTS": "1,2,-3",
+			"BOOLS": "yes",
+			"DURS":  "1s,x",
+			"MAP":   "a=1,b=x",
+			"CSV":   `"a`,
+		},
+	)
+	tests := []struct {
+		name  string
+		err   error
+		key   string
+		value string
+		index int
+		typ   string
+		cause error
+	}{
+		{
+			"int", getErr(env.GetEnvInt8("INT", 0)),
+			"INT", "abc", -1, "int8", strconv.ErrSyntax,
+		},
+		{
+			"uint", getErr(env.GetEnvUint("UINT", 0, 0)),
+			"UINT", "-1", -1, "uint", strconv.ErrSyntax,
+		},
+		{
+			"float", getErr(env.GetEnvFloat32("FLOAT", 0)),
+			"FLOAT", "x", -1, "float32", strconv.ErrSyntax,
+		},
+		{
+			"bool", getErr(env.GetEnvBool("BOOL", false)),
+			"BOOL", "yes", -1, "bool", strconv.ErrSyntax,
+		},
+		{
+			"int csv", getErr(env.GetEnvInt64Csv("INTS", nil)),
+			"INTS", "1,abc", 1, "[]int64", strconv.ErrSyntax,
+		},
+		{
+			"uint csv", getErr(env.GetEnvUint16Csv("UINTS", nil)),
+			"UINTS", "1,2,-3", 2, "[]uint16", strconv.ErrSyntax,
+		},
+		{
+			"float csv", getErr(env.GetEnvFloat64Csv("INTS", nil)),
+			"INTS", "1,abc", 1, "[]float64", strconv.ErrSyntax,
+		},
+		{
+			"bool csv", getErr(env.GetEnvBoolCsv("BOOLS", nil)),
+			"BOOLS", "yes", 0, "[]bool", strconv.ErrSyntax,
+		},
+		{
+			"as", getErr(GetEnvAsFrom(env, "INT", 0)),
+			"INT", "abc", -1, "int", strconv.ErrSyntax,
+		},
+		{
+			"slice as", getErr(env.GetEnvDurationCsv("DURS", nil)),
+			"DURS", "1s,x", 1, "[]time.Duration", nil,
+		},
+		{
+			"map", getErr(env.GetEnvInt64Map("MAP", nil)),
+			"MAP", "a=1,b=x", -1, "map[string]int64", strconv.ErrSyntax,
+		},
+		{
+			"csv", getErr(env.GetEnvCsvWith("CSV", nil, CsvOptions{})),
+			"CSV", `"a`, -1, "[]string", ErrCsvUnterminatedQuote,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var ee *EnvError
+				require.ErrorAs(t, tt.err, &ee)
+				require.Equal(t, tt.key, ee.Key)
+				require.Equal(t, tt.value, ee.Value)
+				require.Equal(t, tt.index, ee.Index)
+				require.Equal(t, tt.typ, ee.Type)
+				if nil != tt.cause {
+					require.ErrorIs(t, tt.err, tt.cause)
+				}
+			},
+		)
+	}
+}
+
+func Test_EnvError_Error_with_index(t *testing.T) {
+	env := NewEnv(MapEnv{"PORTS": "80,abc"})
+	_, err := env.GetEnvUint16Csv("PORTS", nil)
+	require.EqualError(
+		t, err, `invalid environment variable PORTS ([]uint16) value `+
+			`"80,abc": item 1: invalid syntax`,
+	)
+	var cfg struct {
+		Ports []uint16 `env:"PORTS"`
+	}
+	err = env.LoadEnv(&cfg)
+	var ee *EnvError
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, 1, ee.Index)
+	require.Equal(t, "PORTS", ee.Key)
+}
+
+func Test_EnvError_keeps_NumError(t *testing.T) {
+	env := NewEnv(MapEnv{"PORT": "99999", "PORTS": "80,abc"})
+	_, err := env.GetEnvUint16("PORT", 0)
+	var ne *strconv.NumError
+	require.ErrorAs(t, err, &ne)
+	require.Equal(t, "99999", ne.Num)
+	require.ErrorIs(t, err, strconv.ErrRange)
+	require.EqualError(
+		t, err, `invalid environment variable PORT (uint16) value "99999": `+
+			`value out of range`,
+	)
+	_, err = env.GetEnvUint16Csv("PORTS", nil)
+	require.ErrorAs(t, err, &ne)
+	require.Equal(t, "abc", ne.Num)
+}
+
+func getErr[T any](_ T, err error) error {
+	return err
+}
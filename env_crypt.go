@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// EnvMasterKeyName is the variable holding the base64 encoded master key, see
+// `Env.LoadMasterKey()`. The key may also be read from the file named by
+// `ENV_MASTER_KEY_FILE`.
+const EnvMasterKeyName = "ENV_MASTER_KEY"
+
+// EnvMasterKeyLen is the length of master keys, in bytes.
+const EnvMasterKeyLen = 32
+
+var (
+	ErrEnvInvalidKey      = errors.New("master key must be 32 bytes")
+	ErrEnvInvalidEnvelope = errors.New("invalid encrypted value")
+	ErrEnvUnknownCipher   = errors.New("unknown cipher")
+	ErrEnvDecrypt         = errors.New("cannot decrypt value")
+)
+
+// EnvCipher is the algorithm encrypting `ENC(...)` values.
+type EnvCipher byte
+
+const (
+	// XChaCha20-Poly1305, with random 24 bytes nonces
+	EnvXChaCha20Poly1305 EnvCipher = iota + 1
+	// AES-256 in GCM mode, with random 12 bytes nonces
+	EnvAesGcm
+)
+
+func (c EnvCipher) String() string {
+	switch c {
+	case EnvXChaCha20Poly1305:
+		return "XChaCha20-Poly1305"
+	case EnvAesGcm:
+		return "AES-GCM"
+	}
+	return "EnvCipher(" + strconv.Itoa(int(c)) + ")"
+}
+
+// GenerateEnvKey returns a random master key.
+func GenerateEnvKey() ([]byte, error) {
+	key := make([]byte, EnvMasterKeyLen)
+	if _, err := randomBytes(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SealEnv encrypts the value of the variable named by `name` with the master
+// key, returning an envelope of the form `ENC(base64)` to be used as value of
+// the variable. The envelope holds the cipher, a random nonce and the
+// encrypted value, so it can be decrypted by `OpenEnv()` without knowing the
+// cipher. The name is authenticated along with the value, so the envelope
+// cannot be decrypted as the value of another variable. `name` is the full
+// name of the variable, including the `Prefix` of scopes.
+func SealEnv(key []byte, name, value string, c EnvCipher) (string, error) {
+	aead, err := newEnvAead(key, c)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(value)+
+		aead.Overhead())
+	buf[0] = byte(c)
+	if _, err = randomBytes(buf[1:]); err != nil {
+		return "", err
+	}
+	buf = aead.Seal(buf, buf[1:], []byte(value), []byte(name))
+	return "ENC(" + base64.StdEncoding.EncodeToString(buf) + ")", nil
+}
+
+// OpenEnv decrypts the `ENC(...)` envelope returned by `SealEnv()` for the
+// variable named by `name`. An error wrapping `ErrEnvDecrypt` is returned if
+// the key is wrong, the value has been tampered with, or the envelope was
+// sealed for another variable.
+func OpenEnv(key []byte, name, envelope string) (string, error) {
+	if !IsEnvEnvelope(envelope) {
+		return "", ErrEnvInvalidEnvelope
+	}
+	buf, err := base64.StdEncoding.DecodeString(
+		strings.TrimSpace(envelope[4 : len(envelope)-1]),
+	)
+	if err != nil || len(buf) < 1 {
+		return "", ErrEnvInvalidEnvelope
+	}
+	c := EnvCipher(buf[0])
+	aead, err := newEnvAead(key, c)
+	if err != nil {
+		return "", err
+	}
+	buf = buf[1:]
+	if len(buf) < aead.NonceSize()+aead.Overhead() {
+		return "", ErrEnvInvalidEnvelope
+	}
+	nonce, sealed := buf[:aead.NonceSize()], buf[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrEnvDecrypt, c)
+	}
+	return string(plain), nil
+}
+
+// IsEnvEnvelope reports whether the value is an `ENC(...)` envelope. It does
+// not check the content of the envelope.
+func IsEnvEnvelope(s string) bool {
+	return len(s) > 5 && strings.HasPrefix(s, "ENC(") &&
+		strings.HasSuffix(s, ")")
+}
+
+// LoadEnvMasterKey sets the master key of `DefaultEnv`, see
+// `Env.LoadMasterKey()`.
+func LoadEnvMasterKey() error {
+	return DefaultEnv.LoadMasterKey()
+}
+
+// LoadMasterKey sets `MasterKey` to the base64 encoded key in the
+// `ENV_MASTER_KEY` variable, or the file named by `ENV_MASTER_KEY_FILE`. Both
+// variables are read from `Source` without `Prefix`, regardless of
+// `FileSecrets`. `ErrEnvMissing` is returned if neither is set.
+func (e *Env) LoadMasterKey() error {
+	src := Env{Source: e.Source, Usage: e.Usage, FileSecrets: true}
+	key, ok, err := src.parseEnvBytes(
+		EnvMasterKeyName,
+		BytesOptions{Encoding: BytesBase64, Len: EnvMasterKeyLen},
+	)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return newEnvError(EnvMasterKeyName, "", "", ErrEnvMissing)
+	}
+	e.MasterKey = key
+	return nil
+}
+
+func newEnvAead(key []byte, c EnvCipher) (cipher.AEAD, error) {
+	if EnvMasterKeyLen != len(key) {
+		return nil, ErrEnvInvalidKey
+	}
+	switch c {
+	case EnvXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	case EnvAesGcm:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	}
+	return nil, fmt.Errorf("%w: %d", ErrEnvUnknownCipher, c)
+}
@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testEnvKey(tb testing.TB) []byte {
+	key, err := GenerateEnvKey()
+	require.Nil(tb, err)
+	return key
+}
+
+func Test_SealEnv_OpenEnv(t *testing.T) {
+	key := testEnvKey(t)
+	for _, c := range []EnvCipher{EnvXChaCha20Poly1305, EnvAesGcm} {
+		t.Run(
+			c.String(), func(t *testing.T) {
+				sealed, err := SealEnv(key, "PASS", "s3cr3t", c)
+				require.Nil(t, err)
+				require.True(t, IsEnvEnvelope(sealed))
+				again, err := SealEnv(key, "PASS", "s3cr3t", c)
+				require.Nil(t, err)
+				require.NotEqual(t, sealed, again)
+				plain, err := OpenEnv(key, "PASS", sealed)
+				require.Nil(t, err)
+				require.Equal(t, "s3cr3t", plain)
+				_, err = OpenEnv(testEnvKey(t), "PASS", sealed)
+				require.ErrorIs(t, err, ErrEnvDecrypt)
+				require.ErrorContains(t, err, c.String())
+				_, err = OpenEnv(key, "OTHER", sealed)
+				require.ErrorIs(t, err, ErrEnvDecrypt)
+			},
+		)
+	}
+	sealed, err := SealEnv(key, "PASS", "", EnvAesGcm)
+	require.Nil(t, err)
+	plain, err := OpenEnv(key, "PASS", sealed)
+	require.Nil(t, err)
+	require.Empty(t, plain)
+}
+
+func Test_SealEnv_returns_error(t *testing.T) {
+	_, err := SealEnv([]byte("short"), "A", "x", EnvAesGcm)
+	require.ErrorIs(t, err, ErrEnvInvalidKey)
+	_, err = SealEnv(testEnvKey(t), "A", "x", 0)
+	require.ErrorIs(t, err, ErrEnvUnknownCipher)
+	tmp := randomBytes
+	defer func() { randomBytes = tmp }()
+	randomBytes = func([]byte) (int, error) { return 0, errors.New("error") }
+	_, err = SealEnv(make([]byte, EnvMasterKeyLen), "A", "x", EnvAesGcm)
+	require.EqualError(t, err, "error")
+	_, err = GenerateEnvKey()
+	require.EqualError(t, err, "error")
+}
+
+func Test_OpenEnv_returns_error(t *testing.T) {
+	key := testEnvKey(t)
+	sealed, err := SealEnv(key, "PASS", "s3cr3t", EnvXChaCha20Poly1305)
+	require.Nil(t, err)
+	raw, err := base64.StdEncoding.DecodeString(sealed[4 : len(sealed)-1])
+	require.Nil(t, err)
+	envelope := func(b []byte) string {
+		return "ENC(" + base64.StdEncoding.EncodeToString(b) + ")"
+	}
+	tampered := append([]byte{}, raw...)
+	tampered[len(tampered)-1] ^= 1
+	tests := []struct {
+		name     string
+		envelope string
+		err      error
+	}{
+		{"not envelope", "s3cr3t", ErrEnvInvalidEnvelope},
+		{"empty envelope", "ENC()", ErrEnvInvalidEnvelope},
+		{"invalid base64", "ENC(!!)", ErrEnvInvalidEnvelope},
+		{"unknown cipher", envelope([]byte{9, 1, 2}), ErrEnvUnknownCipher},
+		{"truncated", envelope(raw[:20]), ErrEnvInvalidEnvelope},
+		{"tampered", envelope(tampered), ErrEnvDecrypt},
+		{
+			"other cipher", envelope(append([]byte{2}, raw[1:]...)),
+			ErrEnvDecrypt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := OpenEnv(key, "PASS", tt.envelope)
+				require.ErrorIs(t, err, tt.err)
+			},
+		)
+	}
+	_, err = OpenEnv(key[1:], "PASS", sealed)
+	require.ErrorIs(t, err, ErrEnvInvalidKey)
+}
+
+func Test_EnvCipher_String(t *testing.T) {
+	require.Equal(t, "XChaCha20-Poly1305", EnvXChaCha20Poly1305.String())
+	require.Equal(t, "AES-GCM", EnvAesGcm.String())
+	require.Equal(t, "EnvCipher(9)", EnvCipher(9).String())
+}
+
+func Test_Env_Lookup_decrypts_values(t *testing.T) {
+	key := testEnvKey(t)
+	seal := func(name, s string) string {
+		sealed, err := SealEnv(key, name, s, EnvXChaCha20Poly1305)
+		require.Nil(t, err)
+		return sealed
+	}
+	env := NewEnv(
+		MapEnv{
+			"PASS":   seal("PASS", "s3cr3t"),
+			"PORT":   seal("PORT", "8080"),
+			"PORTS":  seal("PORTS", "80,443"),
+			"BAD":    seal("BAD", "x"),
+			"BROKEN": "ENC(!!)",
+			"URL":    "postgres://u:${PASS}@h",
+			"PLAIN":  "plain",
+		},
+	)
+	require.Equal(t, env.Source.(MapEnv)["PASS"], env.MustGetEnv("PASS"))
+	env.MasterKey = key
+	require.Equal(t, "s3cr3t", env.MustGetEnv("PASS"))
+	require.Equal(t, "plain", env.MustGetEnv("PLAIN"))
+	port, err := env.GetEnvUint16("PORT", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint16(8080), port)
+	ports, err := env.GetEnvUint16Csv("PORTS", nil)
+	require.Nil(t, err)
+	require.Equal(t, []uint16{80, 443}, ports)
+	env.Expand = true
+	require.Equal(t, "postgres://u:s3cr3t@h", env.MustGetEnv("URL"))
+
+	_, err = env.GetEnvInt("BAD", 0, 64)
+	var ee *EnvError
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, redactedEnvValue, ee.Value)
+	require.NotContains(t, err.Error(), `"x"`)
+	_, _, err = env.Lookup("BROKEN")
+	require.ErrorIs(t, err, ErrEnvInvalidEnvelope)
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "BROKEN", ee.Key)
+	env.MasterKey = testEnvKey(t)
+	_, _, err = env.Lookup("PASS")
+	require.ErrorIs(t, err, ErrEnvDecrypt)
+}
+
+func Test_Env_Lookup_rejects_envelopes_of_other_variables(t *testing.T) {
+	key := testEnvKey(t)
+	sealed, err := SealEnv(key, "APP_PASS", "s3cr3t", EnvAesGcm)
+	require.Nil(t, err)
+	env := NewEnv(MapEnv{"APP_PASS": sealed, "APP_COPY": sealed})
+	env.MasterKey = key
+	app := env.Scope("APP_")
+	require.Equal(t, "s3cr3t", app.MustGetEnv("PASS"))
+	_, _, err = app.Lookup("COPY")
+	require.ErrorIs(t, err, ErrEnvDecrypt)
+}
+
+func Test_Env_redacts_decrypted_values_in_errors(t *testing.T) {
+	key := testEnvKey(t)
+	sealed, err := SealEnv(key, "PASS", "hunter2", EnvXChaCha20Poly1305)
+	require.Nil(t, err)
+	env := NewEnv(
+		MapEnv{
+			"PASS":     sealed,
+			"URL":      "postgres://u:${PASS}@h:port/x",
+			"N":        "${PASS}",
+			"JSON":     "${PASS}",
+			"REQUIRED": "${PASS}${MISSING:?not set}",
+			"PLAIN":    "x",
+		},
+	)
+	env.MasterKey = key
+	env.Expand = true
+	require.Equal(t, "postgres://u:hunter2@h:port/x", env.MustGetEnv("URL"))
+	errs := []error{
+		getErr(env.GetEnvUrl("URL", nil)),
+		getErr(env.GetEnvInt("N", 0, 64)),
+		getErr(GetEnvJsonFrom[[]int](env, "JSON", nil)),
+		getErr(GetEnvAsFrom(env, "PASS", 0)),
+	}
+	_, _, err = env.Lookup("REQUIRED")
+	errs = append(errs, err)
+	for _, err := range errs {
+		var ee *EnvError
+		require.ErrorAs(t, err, &ee)
+		require.Equal(t, redactedEnvValue, ee.Value)
+		require.NotContains(t, err.Error(), "hunter2")
+	}
+	require.ErrorIs(t, errs[1], strconv.ErrSyntax)
+	require.ErrorContains(t, errs[1], "invalid syntax")
+	require.ErrorIs(t, errs[4], ErrEnvUnsetRef)
+	_, err = env.GetEnvInt("PLAIN", 0, 64)
+	require.ErrorContains(t, err, `"x"`)
+	delete(env.Source.(MapEnv), "REQUIRED")
+	vars, err := env.Vars()
+	require.Nil(t, err)
+	require.Equal(t, redactedEnvValue, vars["URL"])
+	require.Equal(t, "x", vars["PLAIN"])
+}
+
+func Test_Env_LoadMasterKey(t *testing.T) {
+	key := testEnvKey(t)
+	encoded := base64.StdEncoding.EncodeToString(key)
+	file := filepath.Join(t.TempDir(), "key")
+	require.Nil(t, os.WriteFile(file, []byte(encoded+"\n"), 0600))
+	tests := []struct {
+		name string
+		src  MapEnv
+		err  error
+	}{
+		{"variable", MapEnv{EnvMasterKeyName: encoded}, nil},
+		{"file", MapEnv{EnvMasterKeyName + EnvFileSuffix: file}, nil},
+		{"missing", MapEnv{}, ErrEnvMissing},
+		{"short", MapEnv{EnvMasterKeyName: encoded[:8]}, ErrEnvLength},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				env := NewEnv(tt.src).Scope("APP_")
+				err := env.LoadMasterKey()
+				if nil != tt.err {
+					require.ErrorIs(t, err, tt.err)
+					require.ErrorContains(t, err, EnvMasterKeyName)
+					require.Nil(t, env.MasterKey)
+					return
+				}
+				require.Nil(t, err)
+				require.Equal(t, key, env.MasterKey)
+			},
+		)
+	}
+}
+
+func Test_LoadEnvMasterKey(t *testing.T) {
+	key := testEnvKey(t)
+	t.Setenv(EnvMasterKeyName, base64.StdEncoding.EncodeToString(key))
+	defer func() { DefaultEnv.MasterKey = nil }()
+	require.Nil(t, LoadEnvMasterKey())
+	sealed, err := SealEnv(key, "TEST_CRYPT_PASS", "s3cr3t", EnvAesGcm)
+	require.Nil(t, err)
+	t.Setenv("TEST_CRYPT_PASS", sealed)
+	require.Equal(t, "s3cr3t", MustGetEnv("TEST_CRYPT_PASS"))
+	require.False(t, strings.Contains(sealed, "s3cr3t"))
+}
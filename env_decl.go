@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EnvVar describes an environment variable, used to generate documentation.
+type EnvVar struct {
+	// Name of the variable
+	Name string
+	// Type of the value, such as `string`, `uint32` or `[]string`
+	Type string
+	// Value used if the variable is not set
+	Default string
+	// What the variable is for
+	Description string
+	// Whether the variable must be set
+	Required bool
+	// Whether the value is a secret, defaults of sensitive variables are
+	// never written to the documentation
+	Sensitive bool
+}
+
+// EnvVarSet is a set of declared environment variables. It is safe for
+// concurrent use.
+type EnvVarSet struct {
+	mu   sync.Mutex
+	vars map[string]EnvVar
+}
+
+// NewEnvVarSet returns an empty `EnvVarSet`.
+func NewEnvVarSet() *EnvVarSet {
+	return &EnvVarSet{vars: map[string]EnvVar{}}
+}
+
+// Add adds the variables to the set, replacing existing ones of the same name.
+func (s *EnvVarSet) Add(vars ...EnvVar) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nil == s.vars {
+		s.vars = map[string]EnvVar{}
+	}
+	for _, v := range vars {
+		s.vars[v.Name] = v
+	}
+}
+
+// Get returns the variable of the given name. The returned boolean is `false`
+// if the variable has not been declared.
+func (s *EnvVarSet) Get(name string) (EnvVar, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.vars[name]
+	return v, ok
+}
+
+// Vars returns all variables in the set, sorted by name.
+func (s *EnvVarSet) Vars() []EnvVar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vars := make([]EnvVar, 0, len(s.vars))
+	for _, v := range s.vars {
+		vars = append(vars, v)
+	}
+	slices.SortFunc(
+		vars, func(a, b EnvVar) int { return strings.Compare(a.Name, b.Name) },
+	)
+	return vars
+}
+
+// DeclareEnv declares variables of `DefaultEnv`.
+func DeclareEnv(vars ...EnvVar) {
+	DefaultEnv.Declare(vars...)
+}
+
+// DeclaredEnv returns all variables declared in `DefaultEnv`, sorted by name.
+func DeclaredEnv() []EnvVar {
+	return DefaultEnv.DeclaredVars()
+}
+
+// Declare adds the variables to `Declared`, with names prefixed by `Prefix`.
+// It does nothing if `Declared` is `nil`.
+func (e *Env) Declare(vars ...EnvVar) {
+	if nil == e.Declared {
+		return
+	}
+	for _, v := range vars {
+		v.Name = e.Prefix + v.Name
+		e.Declared.Add(v)
+	}
+}
+
+// DeclaredVars returns all variables declared in the `Env` and its scopes,
+// sorted by name.
+func (e *Env) DeclaredVars() []EnvVar {
+	if nil == e.Declared {
+		return nil
+	}
+	return e.Declared.Vars()
+}
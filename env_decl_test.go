@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvVarSet(t *testing.T) {
+	s := NewEnvVarSet()
+	s.Add(EnvVar{Name: "B"}, EnvVar{Name: "A", Type: "int"})
+	s.Add(EnvVar{Name: "A", Type: "uint"})
+	require.Equal(
+		t, []EnvVar{{Name: "A", Type: "uint"}, {Name: "B"}}, s.Vars(),
+	)
+	v, ok := s.Get("A")
+	require.True(t, ok)
+	require.Equal(t, "uint", v.Type)
+	_, ok = s.Get("C")
+	require.False(t, ok)
+	var zero EnvVarSet
+	zero.Add(EnvVar{Name: "A"})
+	require.Len(t, zero.Vars(), 1)
+}
+
+func Test_Env_Declare(t *testing.T) {
+	env := NewEnv(MapEnv{})
+	env.Declare(EnvVar{Name: "HOST"})
+	env.Scope("DB_").Declare(EnvVar{Name: "PORT", Type: "uint16"})
+	require.Equal(
+		t, []EnvVar{{Name: "DB_PORT", Type: "uint16"}, {Name: "HOST"}},
+		env.DeclaredVars(),
+	)
+	noDecl := &Env{Source: MapEnv{}}
+	noDecl.Declare(EnvVar{Name: "HOST"})
+	require.Nil(t, noDecl.DeclaredVars())
+}
+
+func Test_DeclaredEnv_has_password_hash_vars(t *testing.T) {
+	names := Pluck(DeclaredEnv(), func(v EnvVar) string { return v.Name })
+	require.Subset(
+		t, names, []string{
+			PasswordHashTimesName, PasswordHashMemoryName,
+			PasswordHashThreadsName, PasswordHashKeyLenName,
+			PasswordHashSaltLenName,
+		},
+	)
+	DeclareEnv(EnvVar{Name: "TEST_DECLARED_ENV"})
+	v, ok := DefaultEnv.Declared.Get("TEST_DECLARED_ENV")
+	require.True(t, ok)
+	require.Equal(t, "TEST_DECLARED_ENV", v.Name)
+}
+
+func Test_LoadEnv_declares_fields(t *testing.T) {
+	env := NewEnv(MapEnv{"APP_NAME": "app"})
+	var cfg struct {
+		Name     string   `env:"NAME" required:"true" desc:"Name of app"`
+		Password string   `env:"PASSWORD" sensitive:"true"`
+		Ports    []uint16 `env:"PORTS" default:"80,443"`
+	}
+	require.Nil(t, env.Scope("APP_").LoadEnv(&cfg))
+	require.Equal(
+		t, []EnvVar{
+			{
+				Name: "APP_NAME", Type: "string", Description: "Name of app",
+				Required: true,
+			},
+			{Name: "APP_PASSWORD", Type: "string", Sensitive: true},
+			{Name: "APP_PORTS", Type: "[]uint16", Default: "80,443"},
+		},
+		env.DeclaredVars(),
+	)
+}
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EnvJsonSchemaVersion is the JSON schema dialect of `EnvJsonSchema()`.
+const EnvJsonSchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// WriteEnvExample writes the variables in `.env` format. Each variable is
+// preceded by comments of its description and type. Required variables are
+// written as assignments, optional ones are commented out with their default
+// values. Defaults of sensitive variables are omitted.
+func WriteEnvExample(w io.Writer, vars []EnvVar) error {
+	bw := bufio.NewWriter(w)
+	for i, v := range vars {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		for _, line := range strings.Split(v.Description, "\n") {
+			if "" != line {
+				bw.WriteString("# " + line + "\n")
+			}
+		}
+		bw.WriteString("# " + envVarTraits(v) + "\n")
+		if !v.Required {
+			bw.WriteString("# ")
+		}
+		bw.WriteString(v.Name + "=" + quoteDotEnv(envVarDefault(v)) + "\n")
+	}
+	return bw.Flush()
+}
+
+// WriteEnvMarkdown writes the variables as a Markdown table.
+func WriteEnvMarkdown(w io.Writer, vars []EnvVar) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("| Name | Type | Default | Required | Description |\n")
+	bw.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, v := range vars {
+		def := envVarDefault(v)
+		if v.Sensitive {
+			def = "*sensitive*"
+		} else if "" != def {
+			def = "`" + def + "`"
+		}
+		required := "no"
+		if v.Required {
+			required = "yes"
+		}
+		cells := []string{
+			"`" + v.Name + "`", "`" + envVarType(v) + "`", def, required,
+			v.Description,
+		}
+		bw.WriteString("|")
+		for _, cell := range cells {
+			bw.WriteString(" " + escapeMarkdownCell(cell) + " |")
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// EnvJsonSchema returns a JSON schema of an object having the variables as
+// properties. Defaults are converted to the JSON type of the variable when
+// possible. Sensitive variables are marked `writeOnly`, without default.
+func EnvJsonSchema(vars []EnvVar) ([]byte, error) {
+	props := make(map[string]any, len(vars))
+	required := []string{}
+	for _, v := range vars {
+		prop := envJsonSchemaType(envVarType(v))
+		if "" != v.Description {
+			prop["description"] = v.Description
+		}
+		if v.Sensitive {
+			prop["writeOnly"] = true
+		} else if "" != v.Default {
+			prop["default"] = envJsonSchemaValue(prop, v.Default)
+		}
+		props[v.Name] = prop
+		if v.Required {
+			required = append(required, v.Name)
+		}
+	}
+	schema := map[string]any{
+		"$schema":    EnvJsonSchemaVersion,
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+	return Jsoniter.MarshalIndent(schema, "", "  ")
+}
+
+func envVarType(v EnvVar) string {
+	if "" == v.Type {
+		return "string"
+	}
+	return v.Type
+}
+
+func envVarDefault(v EnvVar) string {
+	if v.Sensitive {
+		return ""
+	}
+	return v.Default
+}
+
+// envVarTraits describes type and flags of the variable, such as
+// `uint32, required`.
+func envVarTraits(v EnvVar) string {
+	traits := []string{envVarType(v)}
+	if v.Required {
+		traits = append(traits, "required")
+	}
+	if v.Sensitive {
+		traits = append(traits, "sensitive")
+	}
+	return strings.Join(traits, ", ")
+}
+
+// quoteDotEnv quotes the value if it cannot be written as-is in `.env` files.
+func quoteDotEnv(s string) string {
+	if !strings.ContainsAny(s, " \t\r\n#'\"\\$`") {
+		return s
+	}
+	r := strings.NewReplacer(
+		`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").
+		Replace(s)
+}
+
+// envJsonSchemaType maps Go type names, as given by `reflect.Type.String()`,
+// to JSON schema types.
+func envJsonSchemaType(typ string) map[string]any {
+	typ = strings.TrimLeft(typ, "*")
+	if elem, ok := strings.CutPrefix(typ, "[]"); ok && "byte" != elem &&
+		"uint8" != elem {
+		return map[string]any{"type": "array", "items": envJsonSchemaType(elem)}
+	}
+	if elem, ok := strings.CutPrefix(typ, "map[string]"); ok {
+		return map[string]any{
+			"type": "object", "additionalProperties": envJsonSchemaType(elem),
+		}
+	}
+	switch typ {
+	case "bool":
+		return map[string]any{"type": "boolean"}
+	case "int", "int8", "int16", "int32", "int64":
+		return map[string]any{"type": "integer"}
+	case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr":
+		return map[string]any{"type": "integer", "minimum": 0}
+	case "float32", "float64":
+		return map[string]any{"type": "number"}
+	case "duration", "time.Duration":
+		return map[string]any{"type": "string", "format": "duration"}
+	case "time", "time.Time":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "url", "url.URL":
+		return map[string]any{"type": "string", "format": "uri"}
+	}
+	return map[string]any{"type": "string"}
+}
+
+// envJsonSchemaValue converts the value to the type of the schema, or returns
+// it as-is if it cannot be converted.
+func envJsonSchemaValue(schema map[string]any, s string) any {
+	switch schema["type"] {
+	case "boolean":
+		if b, err := strconv.ParseBool(s); nil == err {
+			return b
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(s, 10, 64); nil == err {
+			return i
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); nil == err {
+			return u
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); nil == err {
+			return f
+		}
+	case "array":
+		items, err := ParseCsv(s, CsvOptions{})
+		if err != nil {
+			return s
+		}
+		elem := schema["items"].(map[string]any)
+		return SliceMapFunc[[]any](
+			items, func(item string) any {
+				return envJsonSchemaValue(elem, item)
+			},
+		)
+	case "object":
+		m, err := ParseEnvMap(s, MapOptions{})
+		if err != nil {
+			return s
+		}
+		elem := schema["additionalProperties"].(map[string]any)
+		ret := make(map[string]any, len(m))
+		for k, v := range m {
+			ret[k] = envJsonSchemaValue(elem, v)
+		}
+		return ret
+	}
+	return s
+}
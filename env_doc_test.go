@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testEnvDocVars = []EnvVar{
+	{
+		Name: "HOST", Type: "string", Default: "localhost",
+		Description: "Host name\nor IP", Required: true,
+	},
+	{Name: "PASSWORD", Default: "secret", Sensitive: true},
+	{Name: "PORTS", Type: "[]uint16", Default: "80,443"},
+	{Name: "GREETING", Default: `say "hi" | $USER`},
+	{Name: "TIMEOUT", Type: "time.Duration", Default: "30s"},
+	{Name: "LIMITS", Type: "map[string]float64", Default: "a=1.5"},
+	{Name: "DEBUG", Type: "*bool", Default: "yes"},
+}
+
+func Test_WriteEnvExample(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, WriteEnvExample(&buf, testEnvDocVars))
+	require.Equal(
+		t, `# Host name
+# or IP
+# string, required
+HOST=localhost
+
+# string, sensitive
+# PASSWORD=
+
+# []uint16
+# PORTS=80,443
+
+# string
+# GREETING="say \"hi\" | \$USER"
+
+# time.Duration
+# TIMEOUT=30s
+
+# map[string]float64
+# LIMITS=a=1.5
+
+# *bool
+# DEBUG=yes
+`, buf.String(),
+	)
+	vars, err := ParseDotEnv(
+		bytes.NewReader([]byte(`GREETING="say \"hi\" | \$USER"`)), nil,
+	)
+	require.Nil(t, err)
+	require.Equal(t, `say "hi" | $USER`, vars["GREETING"])
+}
+
+func Test_WriteEnvMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, WriteEnvMarkdown(&buf, testEnvDocVars[:4]))
+	require.Equal(
+		t, "| Name | Type | Default | Required | Description |\n"+
+			"| --- | --- | --- | --- | --- |\n"+
+			"| `HOST` | `string` | `localhost` | yes | Host name<br>or IP |\n"+
+			"| `PASSWORD` | `string` | *sensitive* | no |  |\n"+
+			"| `PORTS` | `[]uint16` | `80,443` | no |  |\n"+
+			"| `GREETING` | `string` | `say \"hi\" \\| $USER` | no |  |\n",
+		buf.String(),
+	)
+}
+
+func Test_EnvJsonSchema(t *testing.T) {
+	data, err := EnvJsonSchema(testEnvDocVars)
+	require.Nil(t, err)
+	require.JSONEq(
+		t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"HOST": {
+					"type": "string", "default": "localhost",
+					"description": "Host name\nor IP"
+				},
+				"PASSWORD": {"type": "string", "writeOnly": true},
+				"PORTS": {
+					"type": "array",
+					"items": {"type": "integer", "minimum": 0},
+					"default": [80, 443]
+				},
+				"GREETING": {"type": "string", "default": "say \"hi\" | $USER"},
+				"TIMEOUT": {
+					"type": "string", "format": "duration", "default": "30s"
+				},
+				"LIMITS": {
+					"type": "object",
+					"additionalProperties": {"type": "number"},
+					"default": {"a": 1.5}
+				},
+				"DEBUG": {"type": "boolean", "default": "yes"}
+			},
+			"required": ["HOST"]
+		}`, string(data),
+	)
+}
+
+func Test_EnvJsonSchema_empty(t *testing.T) {
+	data, err := EnvJsonSchema(nil)
+	require.Nil(t, err)
+	require.JSONEq(
+		t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object", "properties": {}, "required": []
+		}`, string(data),
+	)
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrEnvCycle    = errors.New("cyclic variable reference")
+	ErrEnvUnsetRef = errors.New("referenced variable not set or empty")
+)
+
+// lookupExpanded looks up the variable and expands references in its value,
+// see `Env.Expand`. `path` holds names of variables being expanded, to detect
+// cycles. The third returned value reports whether the value holds decrypted
+// data, its own or that of a reference, even if an error is returned.
+func (e *Env) lookupExpanded(key string, path []string) (
+	string, bool, bool, error,
+) {
+	val, found, decrypted, err := e.lookup(key)
+	if err != nil || !found {
+		return val, found, decrypted, err
+	}
+	path = append(path, key)
+	ret, err := expandEnvVars(
+		val, `$`, func(name string) (string, bool, error) {
+			if slices.Contains(path, name) {
+				return "", false, fmt.Errorf(
+					"%w: %s", ErrEnvCycle,
+					strings.Join(append(path, name), " -> "),
+				)
+			}
+			v, found, d, err := e.lookupExpanded(name, path)
+			decrypted = decrypted || d
+			return v, found, err
+		},
+	)
+	if nil == err {
+		return ret, true, decrypted, nil
+	}
+	var ee *EnvError
+	if len(path) > 1 || errors.As(err, &ee) {
+		return "", false, decrypted, err
+	}
+	ee = newEnvError(key, val, "", err)
+	if decrypted {
+		ee.redact()
+	}
+	return "", false, decrypted, ee
+}
+
+// expandEnvVars replaces `${VAR}`, `${VAR:-default}` and `${VAR:?error}` in
+// the string with values returned by `lookup`. Unknown variables are replaced
+// by an empty string. Defaults may contain references, such as
+// `${A:-${B}}`. A backslash followed by one of the `escaped` characters, which
+// include `$`, produces that character literally.
+func expandEnvVars(
+	s, escaped string, lookup func(string) (string, bool, error),
+) (string, error) {
+	if !strings.ContainsAny(s, escaped) {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if '\\' == s[i] && i+1 < len(s) &&
+			strings.IndexByte(escaped, s[i+1]) > -1 {
+			sb.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		if '$' != s[i] || i+1 >= len(s) || '{' != s[i+1] {
+			sb.WriteByte(s[i])
+			continue
+		}
+		end := matchEnvBrace(s, i+2)
+		if end < 0 {
+			return "", errors.New("unterminated variable reference")
+		}
+		val, err := expandEnvRef(s[i+2:end], escaped, lookup)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(val)
+		i = end
+	}
+	return sb.String(), nil
+}
+
+// expandEnvRef returns the value of a reference without the enclosing `${}`.
+func expandEnvRef(
+	expr, escaped string, lookup func(string) (string, bool, error),
+) (string, error) {
+	name, def, op := expr, "", ""
+	if i := strings.Index(expr, ":"); i > -1 && i+1 < len(expr) &&
+		('-' == expr[i+1] || '?' == expr[i+1]) {
+		name, def, op = expr[:i], expr[i+2:], expr[i:i+2]
+	}
+	if "" == name {
+		return "", errors.New("empty variable reference")
+	}
+	val, found, err := lookup(name)
+	if err != nil {
+		return "", err
+	}
+	if found && "" != val {
+		return val, nil
+	}
+	switch op {
+	case ":-":
+		return expandEnvVars(def, escaped, lookup)
+	case ":?":
+		if "" == def {
+			return "", fmt.Errorf("%w: %s", ErrEnvUnsetRef, name)
+		}
+		return "", fmt.Errorf("%w: %s: %s", ErrEnvUnsetRef, name, def)
+	}
+	return val, nil
+}
+
+// matchEnvBrace returns the index of the `}` closing the reference starting at
+// `start`, or -1 if there is none.
+func matchEnvBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if 0 == depth {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"flag"
+	"strings"
+)
+
+// EnvWithFlags returns a copy of `DefaultEnv` overridable by flags, see
+// `Env.WithFlags()`.
+func EnvWithFlags(fs *flag.FlagSet) *Env {
+	return DefaultEnv.WithFlags(fs)
+}
+
+// WithFlags registers a flag on `fs` for each declared variable under the
+// scope, see `RegisterEnvFlags()`. It returns a copy of the `Env` reading
+// flags explicitly set on the command line before `Source`, so `-db-host x`
+// overrides `DB_HOST`. Variables have to be declared beforehand, by
+// `Declare()` or `DeclareStruct()`, and `fs` has to be parsed before reading
+// variables.
+//
+// The source of the copy is a `LayeredEnv`, flags being the `LayerFlags`
+// layer. If `Source` is already a `LayeredEnv`, flags are prepended to it.
+func (e *Env) WithFlags(fs *flag.FlagSet) *Env {
+	var vars []EnvVar
+	for _, v := range e.DeclaredVars() {
+		if strings.HasPrefix(v.Name, e.Prefix) {
+			vars = append(vars, v)
+		}
+	}
+	RegisterEnvFlags(fs, vars...)
+	env := *e
+	layers := LayeredEnv{{LayerFlags, FlagEnv{fs}}}
+	if l, ok := e.Source.(LayeredEnv); ok {
+		env.Source = append(layers, l...)
+	} else {
+		env.Source = append(layers, EnvLayer{LayerEnv, e.Source})
+	}
+	return &env
+}
+
+// RegisterEnvFlags registers a string flag on `fs` for each variable, named by
+// `EnvFlagName()`, such as `-db-host` for `DB_HOST`. Boolean variables can be
+// set without value, like `-debug`. The help text is the description of the
+// variable followed by its name, and whether it is required. Defaults of
+// sensitive variables are not shown. Variables whose flag is already defined
+// are skipped.
+//
+// Flag values are not parsed, use `FlagEnv` to read them as variables.
+func RegisterEnvFlags(fs *flag.FlagSet, vars ...EnvVar) {
+	for _, v := range vars {
+		name := EnvFlagName(v.Name)
+		if nil != fs.Lookup(name) {
+			continue
+		}
+		value := &envFlag{isBool: "bool" == strings.TrimLeft(v.Type, "*")}
+		if !v.Sensitive {
+			value.value = v.Default
+		}
+		fs.Var(value, name, envFlagUsage(v))
+	}
+}
+
+func envFlagUsage(v EnvVar) string {
+	var sb strings.Builder
+	if "" != v.Description {
+		sb.WriteString(v.Description)
+		sb.WriteString(" ")
+	}
+	sb.WriteString("(env ")
+	sb.WriteString(v.Name)
+	if v.Required {
+		sb.WriteString(", required")
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// envFlag holds the raw value of a flag registered by `RegisterEnvFlags()`.
+type envFlag struct {
+	value  string
+	isBool bool
+}
+
+func (f *envFlag) String() string {
+	if nil == f {
+		return ""
+	}
+	return f.value
+}
+
+func (f *envFlag) Set(s string) error {
+	f.value = s
+	return nil
+}
+
+func (f *envFlag) IsBoolFlag() bool {
+	return f.isBool
+}
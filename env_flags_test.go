@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEnvFlags struct {
+	Host   string    `env:"HOST" default:"localhost" desc:"Database host"`
+	Port   uint16    `env:"PORT" default:"5432"`
+	Debug  bool      `env:"DEBUG"`
+	Secret string    `env:"SECRET" default:"s3cr3t" sensitive:"true"`
+	Name   string    `env:"NAME" required:"true"`
+	Db     testEnvDb `prefix:"DB_"`
+}
+
+func Test_Env_WithFlags(t *testing.T) {
+	env := NewEnv(MapEnv{"APP_HOST": "env-host", "APP_PORT": "6543"})
+	scope := env.Scope("APP_")
+	require.Nil(t, scope.DeclareStruct(&testEnvFlags{}))
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	scope = scope.WithFlags(fs)
+	require.Nil(
+		t, fs.Parse(
+			[]string{"-app-port", "7000", "-app-debug", "-app-name=app"},
+		),
+	)
+	var cfg testEnvFlags
+	require.Nil(t, scope.LoadEnv(&cfg))
+	require.Equal(
+		t, testEnvFlags{
+			Host:   "env-host",
+			Port:   7000,
+			Debug:  true,
+			Secret: "s3cr3t",
+			Name:   "app",
+			Db:     testEnvDb{"localhost", 5432},
+		}, cfg,
+	)
+	tests := []struct {
+		key    string
+		origin string
+	}{
+		{"PORT", LayerFlags},
+		{"HOST", LayerEnv},
+	}
+	for _, tt := range tests {
+		origin, found := scope.Origin(tt.key)
+		require.True(t, found)
+		require.Equal(t, tt.origin, origin)
+	}
+	_, found := scope.Origin("SECRET")
+	require.False(t, found)
+}
+
+func Test_Env_WithFlags_prepends_layers(t *testing.T) {
+	env := NewEnv(NewLayeredEnv(nil, MapEnv{"X": "file"}, nil))
+	env.Declare(EnvVar{Name: "X"})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	env = env.WithFlags(fs)
+	require.Equal(
+		t, []string{LayerFlags, LayerEnv, LayerFile},
+		Pluck(
+			env.Source.(LayeredEnv), func(l EnvLayer) string { return l.Name },
+		),
+	)
+	require.Nil(t, fs.Parse([]string{"-x=flag"}))
+	require.Equal(t, "flag", env.MustGetEnv("X"))
+}
+
+func Test_Env_WithFlags_registers_scope_vars_only(t *testing.T) {
+	env := NewEnv(MapEnv{})
+	env.Declare(EnvVar{Name: "APP_X"}, EnvVar{Name: "OTHER_X"})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	env.Scope("APP_").WithFlags(fs)
+	require.NotNil(t, fs.Lookup("app-x"))
+	require.Nil(t, fs.Lookup("other-x"))
+}
+
+func Test_RegisterEnvFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db-host", "", "existing")
+	RegisterEnvFlags(
+		fs,
+		EnvVar{Name: "DB_HOST", Default: "localhost"},
+		EnvVar{
+			Name: "DB_PORT", Type: "uint16", Default: "5432",
+			Description: "Database port",
+		},
+		EnvVar{Name: "DEBUG", Type: "*bool", Required: true},
+		EnvVar{Name: "SECRET", Default: "s3cr3t", Sensitive: true},
+	)
+	require.Equal(t, "existing", fs.Lookup("db-host").Usage)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	require.Equal(
+		t, `  -db-host string
+    	existing
+  -db-port value
+    	Database port (env DB_PORT) (default 5432)
+  -debug
+    	(env DEBUG, required)
+  -secret value
+    	(env SECRET)
+`, out.String(),
+	)
+	require.Nil(t, fs.Parse([]string{"-debug", "-secret", "x"}))
+	require.Equal(t, "true", fs.Lookup("debug").Value.String())
+	require.Equal(t, "x", fs.Lookup("secret").Value.String())
+}
+
+func Test_DeclareEnvStruct(t *testing.T) {
+	require.Nil(t, DeclareEnvStruct(&testEnvFlags{}))
+	v, ok := DefaultEnv.Declared.Get("DB_PORT")
+	require.True(t, ok)
+	require.Equal(
+		t, EnvVar{Name: "DB_PORT", Type: "uint16", Default: "5432"}, v,
+	)
+	_, ok = DefaultEnv.Declared.Get("DB")
+	require.False(t, ok)
+	require.ErrorIs(t, DeclareEnvStruct(testEnvFlags{}), ErrInvalidEnvTarget)
+	require.ErrorIs(t, DeclareEnvStruct(nil), ErrInvalidEnvTarget)
+}
+
+func Test_EnvWithFlags(t *testing.T) {
+	t.Setenv("TEST_FLAGS_X", "env")
+	DeclareEnv(EnvVar{Name: "TEST_FLAGS_X"})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	env := EnvWithFlags(fs)
+	require.Equal(t, "env", env.MustGetEnv("TEST_FLAGS_X"))
+	require.Nil(t, fs.Parse([]string{"-test-flags-x", "flag"}))
+	require.Equal(t, "flag", env.MustGetEnv("TEST_FLAGS_X"))
+	require.Equal(t, "env", MustGetEnv("TEST_FLAGS_X"))
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/json-iterator/go"
+)
+
+var (
+	// same as `Jsoniter`, rejecting unknown fields
+	jsoniterStrict = jsoniter.Config{
+		EscapeHTML:             true,
+		SortMapKeys:            true,
+		ValidateJsonRawMessage: true,
+		DisallowUnknownFields:  true,
+	}.Froze()
+)
+
+// EnvJsonError is the error of decoding a JSON value, with the byte offset in
+// the value where the problem is found. Since the offset locates the problem,
+// `EnvError` messages do not repeat the value.
+type EnvJsonError struct {
+	// Number of bytes read before the problem was detected, same as
+	// `json.SyntaxError`, or -1 if unknown
+	Offset int
+	// The reason of the problem
+	Err error
+}
+
+func (e *EnvJsonError) Error() string {
+	if e.Offset < 0 {
+		return e.Err.Error()
+	}
+	return "offset " + strconv.Itoa(e.Offset) + ": " + e.Err.Error()
+}
+
+func (e *EnvJsonError) Unwrap() error {
+	return e.Err
+}
+
+// GetEnvJson decodes the value of the environment variable named by the key
+// as JSON into `T`, using the `Jsoniter` config. It is guaranteed to return
+// the default value if the environment variable is not found or empty.
+// Problems are reported as `*EnvError` wrapping `*EnvJsonError`.
+func GetEnvJson[T any](key string, defaultValue T) (T, error) {
+	return GetEnvJsonFrom(DefaultEnv, key, defaultValue)
+}
+
+// GetEnvJsonFrom is the same as `GetEnvJson()`, reading from the given `Env`.
+// Unknown object fields are rejected if `Env.StrictJson` is set.
+func GetEnvJsonFrom[T any](e *Env, key string, defaultValue T) (T, error) {
+	ret, ok, err := parseEnvJson[T](e, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// MustGetEnvJson decodes the value of the environment variable named by the
+// key as JSON into `T`. It panics if the environment variable is not found,
+// empty, or cannot be decoded.
+func MustGetEnvJson[T any](key string) T {
+	return MustGetEnvJsonFrom[T](DefaultEnv, key)
+}
+
+// MustGetEnvJsonFrom is the same as `MustGetEnvJson()`, reading from the given
+// `Env`.
+func MustGetEnvJsonFrom[T any](e *Env, key string) T {
+	ret, ok, err := parseEnvJson[T](e, key)
+	PanicIfError(err)
+	if !ok {
+		panic(ErrEnvMissing.Error() + ": " + e.Prefix + key)
+	}
+	return ret
+}
+
+// parseEnvJson decodes the value of the variable named by the key. The
+// returned boolean is `false` if the variable is not found or empty.
+func parseEnvJson[T any](e *Env, key string) (T, bool, error) {
+	return parseEnvScalar(
+		e, key, reflect.TypeFor[T]().String(), func(s string) (T, error) {
+			var v T
+			err := decodeEnvJson(s, &v, e.StrictJson)
+			return v, err
+		},
+	)
+}
+
+// decodeEnvJson decodes the whole string into `v`, rejecting trailing data.
+func decodeEnvJson(s string, v any, strict bool) error {
+	api := Jsoniter
+	if strict {
+		api = jsoniterStrict
+	}
+	if err := api.UnmarshalFromString(s, v); err != nil {
+		return newEnvJsonError(s, reflect.TypeOf(v).Elem(), strict, err)
+	}
+	return nil
+}
+
+// newEnvJsonError describes the failure of decoding the string into a value
+// of the type. Since Jsoniter does not expose the position of errors, the
+// string is decoded again by `encoding/json`, whose errors carry the offset.
+// The Jsoniter error is kept if `encoding/json` does not fail the same way,
+// without its excerpts of the value.
+func newEnvJsonError(
+	s string, typ reflect.Type, strict bool, err error,
+) *EnvJsonError {
+	jerr := json.Unmarshal([]byte(s), reflect.New(typ).Interface())
+	if nil == jerr && strict {
+		dec := json.NewDecoder(strings.NewReader(s))
+		dec.DisallowUnknownFields()
+		jerr = dec.Decode(reflect.New(typ).Interface())
+	}
+	var se *json.SyntaxError
+	if errors.As(jerr, &se) {
+		return &EnvJsonError{Offset: int(se.Offset), Err: jerr}
+	}
+	var te *json.UnmarshalTypeError
+	if errors.As(jerr, &te) {
+		return &EnvJsonError{Offset: int(te.Offset), Err: jerr}
+	}
+	if nil != jerr {
+		return &EnvJsonError{Offset: -1, Err: jerr}
+	}
+	msg, _, _ := strings.Cut(err.Error(), ", error found in #")
+	return &EnvJsonError{Offset: -1, Err: errors.New(msg)}
+}
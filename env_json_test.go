@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEnvJsonRule struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+func Test_GetEnvJson(t *testing.T) {
+	t.Setenv(
+		"TEST_ENV_JSON", `[{"name":"a","enabled":true},{"name":"b"}]`,
+	)
+	t.Setenv("TEST_ENV_JSON_EMPTY", "")
+	got, err := GetEnvJson[[]testEnvJsonRule]("TEST_ENV_JSON", nil)
+	require.Nil(t, err)
+	require.Equal(t, []testEnvJsonRule{{"a", true}, {"b", false}}, got)
+	def := []testEnvJsonRule{{"d", true}}
+	got, err = GetEnvJson("TEST_ENV_JSON_EMPTY", def)
+	require.Nil(t, err)
+	require.Equal(t, def, got)
+	got, err = GetEnvJson("TEST_ENV_JSON_MISSING", def)
+	require.Nil(t, err)
+	require.Equal(t, def, got)
+	m, err := GetEnvJson[map[string]any]("TEST_ENV_JSON", nil)
+	require.NotNil(t, err)
+	require.Nil(t, m)
+}
+
+func Test_GetEnvJson_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		strict bool
+		offset int
+	}{
+		{"type mismatch", `[{"name":1}]`, false, 10},
+		{"syntax", `[{"name":"a"},]`, false, 15},
+		{"trailing data", `[{"name":"a"}] x`, false, 16},
+		{"not array", `{`, false, 1},
+		{"unknown field", `[{"name":"a","x":1}]`, true, -1},
+		{
+			"repeated snippet",
+			`[{"name":"a"},{"name":"a"},{"name":"a"},{"name":1}]`,
+			false, 49,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				env := NewEnv(MapEnv{"RULES": tt.value})
+				env.StrictJson = tt.strict
+				_, err := GetEnvJsonFrom[[]testEnvJsonRule](env, "RULES", nil)
+				var ee *EnvError
+				require.ErrorAs(t, err, &ee)
+				require.Equal(t, "RULES", ee.Key)
+				require.Equal(t, tt.value, ee.Value)
+				require.Equal(t, "[]utils.testEnvJsonRule", ee.Type)
+				var je *EnvJsonError
+				require.ErrorAs(t, err, &je)
+				require.Equal(t, tt.offset, je.Offset)
+				require.NotContains(t, err.Error(), tt.value)
+				require.NotContains(t, err.Error(), "bigger context")
+			},
+		)
+	}
+}
+
+func Test_GetEnvJson_unknown_fields_allowed(t *testing.T) {
+	env := NewEnv(MapEnv{"RULES": `[{"name":"a","x":1}]`})
+	got, err := GetEnvJsonFrom[[]testEnvJsonRule](env, "RULES", nil)
+	require.Nil(t, err)
+	require.Equal(t, []testEnvJsonRule{{Name: "a"}}, got)
+}
+
+func Test_GetEnvJson_file(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.json")
+	require.Nil(t, os.WriteFile(file, []byte(`{"name":"f"}`+"\n"), 0600))
+	env := NewEnv(MapEnv{"RULE_FILE": file})
+	env.FileSecrets = true
+	got := MustGetEnvJsonFrom[testEnvJsonRule](env, "RULE")
+	require.Equal(t, testEnvJsonRule{Name: "f"}, got)
+}
+
+func Test_MustGetEnvJson(t *testing.T) {
+	t.Setenv("TEST_ENV_JSON", `{"name":"a"}`)
+	t.Setenv("TEST_ENV_JSON_INVALID", `{"name":`)
+	require.Equal(
+		t, testEnvJsonRule{Name: "a"},
+		MustGetEnvJson[testEnvJsonRule]("TEST_ENV_JSON"),
+	)
+	require.PanicsWithValue(
+		t, "missing environment variable: TEST_ENV_JSON_MISSING", func() {
+			MustGetEnvJson[testEnvJsonRule]("TEST_ENV_JSON_MISSING")
+		},
+	)
+	require.Panics(
+		t, func() {
+			MustGetEnvJson[testEnvJsonRule]("TEST_ENV_JSON_INVALID")
+		},
+	)
+}
+
+func Test_GetEnvJson_error_message(t *testing.T) {
+	env := NewEnv(MapEnv{"RULES": `[{"name":"a"},]`})
+	_, err := GetEnvJsonFrom[[]testEnvJsonRule](env, "RULES", nil)
+	require.EqualError(
+		t, err, "invalid environment variable RULES "+
+			"([]utils.testEnvJsonRule): offset 15: invalid character ']' "+
+			"looking for beginning of value",
+	)
+	var se *json.SyntaxError
+	require.ErrorAs(t, err, &se)
+}
+
+func Test_newEnvJsonError_keeps_jsoniter_error(t *testing.T) {
+	err := newEnvJsonError(
+		"1", reflect.TypeFor[int](), false, errors.New(
+			"ReadInt: invalid, error found in #1 byte of ...|1|..., "+
+				"bigger context ...|1|...",
+		),
+	)
+	require.Equal(t, -1, err.Offset)
+	require.EqualError(t, err, "ReadInt: invalid")
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// EnvParseMode enables lenient parsing of booleans and integers. Values are
+// parsed by `strconv` in base 10 if no mode is set.
+type EnvParseMode uint
+
+const (
+	// EnvHumanBools accepts `yes`/`no`, `y`/`n`, `on`/`off` and
+	// `enable(d)`/`disable(d)` as booleans, case-insensitively.
+	EnvHumanBools EnvParseMode = 1 << iota
+	// EnvBasePrefixes accepts `0x`, `0o` and `0b` prefixed integers. Numbers
+	// with leading zeros are still decimal.
+	EnvBasePrefixes
+	// EnvUnderscores accepts underscores between digits, such as `1_000`.
+	EnvUnderscores
+	// EnvSuffixes accepts `k`, `M` and `G` suffixes, multiplying integers by
+	// 1000, 1000² and 1000³.
+	EnvSuffixes
+	// EnvLenient enables all modes.
+	EnvLenient = EnvHumanBools | EnvBasePrefixes | EnvUnderscores | EnvSuffixes
+)
+
+var (
+	envHumanTrue = []string{
+		"1", "t", "true", "y", "yes", "on", "enable", "enabled",
+	}
+	envHumanFalse = []string{
+		"0", "f", "false", "n", "no", "off", "disable", "disabled",
+	}
+	envIntSuffixes = map[byte]uint64{
+		'k': 1e3, 'K': 1e3, 'M': 1e6, 'G': 1e9,
+	}
+)
+
+// ParseEnvBool parses the string as a boolean using the mode. Errors are
+// `*strconv.NumError`, same as `strconv.ParseBool()`.
+func ParseEnvBool(s string, mode EnvParseMode) (bool, error) {
+	if 0 == mode&EnvHumanBools {
+		return strconv.ParseBool(s)
+	}
+	l := strings.ToLower(s)
+	for _, v := range envHumanTrue {
+		if v == l {
+			return true, nil
+		}
+	}
+	for _, v := range envHumanFalse {
+		if v == l {
+			return false, nil
+		}
+	}
+	return false, &strconv.NumError{
+		Func: "ParseBool", Num: s, Err: strconv.ErrSyntax,
+	}
+}
+
+// ParseEnvInt parses the string as an integer of the bit size using the mode.
+// Errors are `*strconv.NumError`, same as `strconv.ParseInt()`.
+func ParseEnvInt(s string, bitSize int, mode EnvParseMode) (int64, error) {
+	if 0 == mode&(EnvBasePrefixes|EnvUnderscores|EnvSuffixes) {
+		return strconv.ParseInt(s, 10, bitSize)
+	}
+	if 0 == bitSize {
+		bitSize = strconv.IntSize
+	}
+	digits, neg := strings.CutPrefix(s, "-")
+	if !neg {
+		digits = strings.TrimPrefix(digits, "+")
+	}
+	u, err := parseEnvUintLiteral(digits, mode)
+	if err != nil {
+		return 0, &strconv.NumError{Func: "ParseInt", Num: s, Err: err}
+	}
+	limit := uint64(1) << (bitSize - 1)
+	if !neg && u >= limit || neg && u > limit {
+		return 0, &strconv.NumError{
+			Func: "ParseInt", Num: s, Err: strconv.ErrRange,
+		}
+	}
+	if neg {
+		return -int64(u), nil
+	}
+	return int64(u), nil
+}
+
+// ParseEnvUint parses the string as an unsigned integer of the bit size using
+// the mode. Errors are `*strconv.NumError`, same as `strconv.ParseUint()`.
+func ParseEnvUint(s string, bitSize int, mode EnvParseMode) (uint64, error) {
+	if 0 == mode&(EnvBasePrefixes|EnvUnderscores|EnvSuffixes) {
+		return strconv.ParseUint(s, 10, bitSize)
+	}
+	if 0 == bitSize {
+		bitSize = strconv.IntSize
+	}
+	u, err := parseEnvUintLiteral(strings.TrimPrefix(s, "+"), mode)
+	if nil == err && bitSize < 64 && u > uint64(1)<<bitSize-1 {
+		err = strconv.ErrRange
+	}
+	if err != nil {
+		return 0, &strconv.NumError{Func: "ParseUint", Num: s, Err: err}
+	}
+	return u, nil
+}
+
+// parseEnvUintLiteral parses an unsigned integer literal using the mode. It
+// returns `strconv.ErrSyntax` or `strconv.ErrRange` on failure.
+func parseEnvUintLiteral(s string, mode EnvParseMode) (uint64, error) {
+	mul := uint64(1)
+	if 0 != mode&EnvSuffixes && "" != s {
+		if m, ok := envIntSuffixes[s[len(s)-1]]; ok {
+			s, mul = s[:len(s)-1], m
+		}
+	}
+	base := 10
+	if 0 != mode&EnvBasePrefixes && len(s) > 2 && '0' == s[0] {
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if 10 != base {
+			s = s[2:]
+		}
+	}
+	if 0 != mode&EnvUnderscores && strings.Contains(s, "_") {
+		if strings.HasPrefix(s, "_") || strings.HasSuffix(s, "_") ||
+			strings.Contains(s, "__") {
+			return 0, strconv.ErrSyntax
+		}
+		s = strings.ReplaceAll(s, "_", "")
+	}
+	// signs have been removed by callers
+	if "" == s || '+' == s[0] || '-' == s[0] {
+		return 0, strconv.ErrSyntax
+	}
+	u, err := strconv.ParseUint(s, base, 64)
+	if err != nil {
+		return 0, err.(*strconv.NumError).Err
+	}
+	hi, lo := bits.Mul64(u, mul)
+	if 0 != hi {
+		return 0, strconv.ErrRange
+	}
+	return lo, nil
+}
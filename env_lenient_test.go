@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseEnvBool(t *testing.T) {
+	tests := []struct {
+		input   string
+		mode    EnvParseMode
+		want    bool
+		wantErr error
+	}{
+		{"true", 0, true, nil},
+		{"yes", 0, false, strconv.ErrSyntax},
+		{"yes", EnvHumanBools, true, nil},
+		{"Y", EnvHumanBools, true, nil},
+		{"ON", EnvHumanBools, true, nil},
+		{"enabled", EnvHumanBools, true, nil},
+		{"Enable", EnvHumanBools, true, nil},
+		{"no", EnvHumanBools, false, nil},
+		{"n", EnvHumanBools, false, nil},
+		{"Off", EnvHumanBools, false, nil},
+		{"disabled", EnvHumanBools, false, nil},
+		{"FALSE", EnvHumanBools, false, nil},
+		{"0", EnvLenient, false, nil},
+		{"maybe", EnvHumanBools, false, strconv.ErrSyntax},
+		{"", EnvHumanBools, false, strconv.ErrSyntax},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.input, func(t *testing.T) {
+				got, err := ParseEnvBool(tt.input, tt.mode)
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func Test_ParseEnvInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		bitSize int
+		mode    EnvParseMode
+		want    int64
+		wantErr error
+	}{
+		{"strict", "-12", 64, 0, -12, nil},
+		{"strict hex", "0x1F", 64, 0, 0, strconv.ErrSyntax},
+		{"hex", "0x1F", 64, EnvBasePrefixes, 31, nil},
+		{"negative hex", "-0X1f", 64, EnvBasePrefixes, -31, nil},
+		{"octal", "0o17", 64, EnvBasePrefixes, 15, nil},
+		{"binary", "+0b101", 64, EnvBasePrefixes, 5, nil},
+		{"leading zero", "010", 64, EnvBasePrefixes, 10, nil},
+		{"prefix only", "0x", 64, EnvBasePrefixes, 0, strconv.ErrSyntax},
+		{"prefix disabled", "0x1F", 64, EnvUnderscores, 0, strconv.ErrSyntax},
+		{"underscores", "1_000_000", 64, EnvUnderscores, 1000000, nil},
+		{"hex underscores", "0xFF_FF", 64, EnvLenient, 65535, nil},
+		{"leading underscore", "_1", 64, EnvUnderscores, 0, strconv.ErrSyntax},
+		{"trailing underscore", "1_", 64, EnvUnderscores, 0, strconv.ErrSyntax},
+		{"double underscore", "1__0", 64, EnvUnderscores, 0, strconv.ErrSyntax},
+		{"kilo", "2k", 64, EnvSuffixes, 2000, nil},
+		{"kilo upper", "2K", 64, EnvSuffixes, 2000, nil},
+		{"mega", "-3M", 64, EnvSuffixes, -3000000, nil},
+		{"giga", "1_5G", 64, EnvLenient, 15000000000, nil},
+		{"suffix disabled", "2k", 64, EnvUnderscores, 0, strconv.ErrSyntax},
+		{"suffix only", "k", 64, EnvSuffixes, 0, strconv.ErrSyntax},
+		{"double sign", "--1", 64, EnvLenient, 0, strconv.ErrSyntax},
+		{"max int8", "127", 8, EnvLenient, 127, nil},
+		{"min int8", "-0x80", 8, EnvLenient, -128, nil},
+		{"int8 overflow", "128", 8, EnvLenient, 0, strconv.ErrRange},
+		{"int8 underflow", "-129", 8, EnvLenient, 0, strconv.ErrRange},
+		{"suffix overflow", "1k", 8, EnvLenient, 0, strconv.ErrRange},
+		{"int", "9G", 0, EnvLenient, 9000000000, nil},
+		{"min int64", "-9223372036854775808", 64, EnvLenient, math.MinInt64, nil},
+		{
+			"multiply overflow", "18446744073709551615k", 64, EnvLenient, 0,
+			strconv.ErrRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := ParseEnvInt(tt.input, tt.bitSize, tt.mode)
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Equal(t, tt.want, got)
+				if nil != err {
+					var ne *strconv.NumError
+					require.ErrorAs(t, err, &ne)
+					require.Equal(t, tt.input, ne.Num)
+				}
+			},
+		)
+	}
+}
+
+func Test_ParseEnvUint(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		bitSize int
+		mode    EnvParseMode
+		want    uint64
+		wantErr error
+	}{
+		{"strict", "12", 64, 0, 12, nil},
+		{"hex", "0x1F", 64, EnvLenient, 31, nil},
+		{"plus", "+1k", 64, EnvLenient, 1000, nil},
+		{"negative", "-1", 64, EnvLenient, 0, strconv.ErrSyntax},
+		{"max uint8", "0xff", 8, EnvLenient, 255, nil},
+		{"uint8 overflow", "256", 8, EnvLenient, 0, strconv.ErrRange},
+		{"uint16 suffix", "65k", 16, EnvLenient, 65000, nil},
+		{
+			"max uint64", "18_446_744_073_709_551_615", 64, EnvLenient,
+			math.MaxUint64, nil,
+		},
+		{"uint64 overflow", "20G", 32, EnvLenient, 0, strconv.ErrRange},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := ParseEnvUint(tt.input, tt.bitSize, tt.mode)
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func Test_Env_Parse_mode(t *testing.T) {
+	env := NewEnv(
+		MapEnv{
+			"ENABLED":  "yes",
+			"FLAGS":    "on,off,enabled",
+			"MAX_BODY": "1_000_000",
+			"MASK":     "0x1F",
+			"SIZES":    "1k, 2M",
+			"PORT":     "8k",
+		},
+	)
+	_, err := env.GetEnvBool("ENABLED", false)
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	env.Parse = EnvLenient
+	enabled, err := env.GetEnvBool("ENABLED", false)
+	require.Nil(t, err)
+	require.True(t, enabled)
+	flags, err := env.GetEnvBoolCsv("FLAGS", nil)
+	require.Nil(t, err)
+	require.Equal(t, []bool{true, false, true}, flags)
+	maxBody, err := env.GetEnvInt64("MAX_BODY", 0)
+	require.Nil(t, err)
+	require.Equal(t, int64(1000000), maxBody)
+	mask, err := env.GetEnvUint8("MASK", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint8(31), mask)
+	sizes, err := env.GetEnvUintCsv("SIZES", nil, 0)
+	require.Nil(t, err)
+	require.Equal(t, []uint64{1000, 2000000}, sizes)
+	port, err := GetEnvAsFrom[uint16](env, "PORT", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint16(8000), port)
+	ints, err := GetEnvSliceAsFrom[int](env, "SIZES", nil)
+	require.Nil(t, err)
+	require.Equal(t, []int{1000, 2000000}, ints)
+	_, err = env.GetEnvInt8("PORT", 0)
+	require.ErrorIs(t, err, strconv.ErrRange)
+	var cfg struct {
+		Enabled bool    `env:"ENABLED"`
+		Sizes   []int32 `env:"SIZES"`
+		Mask    *int    `env:"MASK"`
+	}
+	require.Nil(t, env.LoadEnv(&cfg))
+	require.True(t, cfg.Enabled)
+	require.Equal(t, []int32{1000, 2000000}, cfg.Sizes)
+	require.Equal(t, 31, *cfg.Mask)
+	require.Equal(t, 31, ReturnOrPanic(ParseEnvValue[int]("31")))
+	_, err = ParseEnvValue[int]("0x1F")
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+}
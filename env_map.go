@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DuplicateKeyPolicy decides what happens when a key appears more than once
+// in a map variable.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyError fails with `ErrEnvDuplicateKey`
+	DuplicateKeyError DuplicateKeyPolicy = iota
+	// DuplicateKeyFirst keeps the first value
+	DuplicateKeyFirst
+	// DuplicateKeyLast keeps the last value
+	DuplicateKeyLast
+)
+
+var (
+	ErrEnvDuplicateKey  = errors.New("duplicate key")
+	ErrEnvInvalidMapKey = errors.New("entry has no key")
+)
+
+// MapOptions controls how `ParseEnvMap()` splits a string into key/value
+// pairs. The zero value parses `k1=v1,k2=v2` and rejects duplicate keys.
+type MapOptions struct {
+	// How the string is split into entries, see `ParseCsv()`
+	Entries CsvOptions
+	// Separator between key and value of an entry, defaults to `=`
+	Separator rune
+	// What to do with keys appearing more than once
+	Duplicates DuplicateKeyPolicy
+}
+
+// ParseEnvMap splits the string into entries using `ParseCsv()`, then splits
+// each entry into key and value at the first pair separator. Keys and values
+// are trimmed. Entries without a separator map the key to an empty value.
+func ParseEnvMap(s string, opts MapOptions) (map[string]string, error) {
+	sep := opts.Separator
+	if 0 == sep {
+		sep = '='
+	}
+	entries, err := ParseCsv(s, opts.Entries)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		k, v, _ := strings.Cut(entry, string(sep))
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if "" == k {
+			return nil, fmt.Errorf("entry %d: %w", i, ErrEnvInvalidMapKey)
+		}
+		if _, ok := m[k]; ok {
+			switch opts.Duplicates {
+			case DuplicateKeyFirst:
+				continue
+			case DuplicateKeyError:
+				return nil, fmt.Errorf("entry %d: %w %q", i, ErrEnvDuplicateKey, k)
+			}
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// GetEnvMap returns the value of the environment variable named by the key
+// as a map, such as `team=core,env=prod`. It is guaranteed to return the
+// default value if the environment variable is not found or empty. See
+// `ParseEnvMap()` for the format of the value.
+func GetEnvMap(key string, defaultValue map[string]string) (
+	map[string]string, error,
+) {
+	return DefaultEnv.GetEnvMap(key, defaultValue)
+}
+
+// GetEnvMapWith returns the value of the environment variable named by the
+// key as a map parsed as specified by `opts`. It is guaranteed to return the
+// default value if the environment variable is not found or empty.
+func GetEnvMapWith(
+	key string, defaultValue map[string]string, opts MapOptions,
+) (map[string]string, error) {
+	return DefaultEnv.GetEnvMapWith(key, defaultValue, opts)
+}
+
+// GetEnvMapAs returns the value of the environment variable named by the key
+// as a map of `V`. It is guaranteed to return the default value if the
+// environment variable is not found or empty. See `ParseEnvValue()` for
+// supported types.
+func GetEnvMapAs[V any](key string, defaultValue map[string]V) (
+	map[string]V, error,
+) {
+	return GetEnvMapAsFrom(DefaultEnv, key, defaultValue)
+}
+
+// GetEnvMapAsFrom is the same as `GetEnvMapAs()`, reading from the given
+// `Env`.
+func GetEnvMapAsFrom[V any](e *Env, key string, defaultValue map[string]V) (
+	map[string]V, error,
+) {
+	typ := reflect.TypeFor[map[string]V]().String()
+	ret, ok, err := parseEnvScalar(
+		e, key, typ, func(s string) (map[string]V, error) {
+			m, err := ParseEnvMap(s, e.Map)
+			if err != nil {
+				return nil, err
+			}
+			ret := make(map[string]V, len(m))
+			for k, s := range m {
+				v, err := parseEnvValueAs[V](s, e.Parse)
+				if err != nil {
+					return nil, fmt.Errorf("key %q: %w", k, err)
+				}
+				ret[k] = v
+			}
+			return ret, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvInt64Map returns the value of the environment variable named by the
+// key as a map of int64s. It is guaranteed to return the default value if the
+// environment variable is not found or empty.
+func GetEnvInt64Map(key string, defaultValue map[string]int64) (
+	map[string]int64, error,
+) {
+	return DefaultEnv.GetEnvInt64Map(key, defaultValue)
+}
+
+// GetEnvUint64Map returns the value of the environment variable named by the
+// key as a map of uint64s. It is guaranteed to return the default value if
+// the environment variable is not found or empty.
+func GetEnvUint64Map(key string, defaultValue map[string]uint64) (
+	map[string]uint64, error,
+) {
+	return DefaultEnv.GetEnvUint64Map(key, defaultValue)
+}
+
+// GetEnvFloat64Map returns the value of the environment variable named by the
+// key as a map of float64s. It is guaranteed to return the default value if
+// the environment variable is not found or empty.
+func GetEnvFloat64Map(key string, defaultValue map[string]float64) (
+	map[string]float64, error,
+) {
+	return DefaultEnv.GetEnvFloat64Map(key, defaultValue)
+}
+
+// GetEnvBoolMap returns the value of the environment variable named by the
+// key as a map of bools. It is guaranteed to return the default value if the
+// environment variable is not found or empty.
+func GetEnvBoolMap(key string, defaultValue map[string]bool) (
+	map[string]bool, error,
+) {
+	return DefaultEnv.GetEnvBoolMap(key, defaultValue)
+}
+
+// GetEnvDurationMap returns the value of the environment variable named by
+// the key as a map of durations. It is guaranteed to return the default value
+// if the environment variable is not found or empty.
+func GetEnvDurationMap(key string, defaultValue map[string]time.Duration) (
+	map[string]time.Duration, error,
+) {
+	return DefaultEnv.GetEnvDurationMap(key, defaultValue)
+}
+
+// GetEnvMap returns the value of the variable named by the key as a map
+// parsed as specified by `Map`, or the default value if the variable is not
+// found or empty.
+func (e *Env) GetEnvMap(key string, defaultValue map[string]string) (
+	map[string]string, error,
+) {
+	return e.GetEnvMapWith(key, defaultValue, e.Map)
+}
+
+// GetEnvMapWith returns the value of the variable named by the key as a map
+// parsed as specified by `opts`, or the default value if the variable is not
+// found or empty.
+func (e *Env) GetEnvMapWith(
+	key string, defaultValue map[string]string, opts MapOptions,
+) (map[string]string, error) {
+	ret, ok, err := parseEnvScalar(
+		e, key, "map[string]string", func(s string) (map[string]string, error) {
+			return ParseEnvMap(s, opts)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvInt64Map returns the value of the variable named by the key as a map
+// of int64s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvInt64Map(key string, defaultValue map[string]int64) (
+	map[string]int64, error,
+) {
+	return GetEnvMapAsFrom(e, key, defaultValue)
+}
+
+// GetEnvUint64Map returns the value of the variable named by the key as a map
+// of uint64s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUint64Map(key string, defaultValue map[string]uint64) (
+	map[string]uint64, error,
+) {
+	return GetEnvMapAsFrom(e, key, defaultValue)
+}
+
+// GetEnvFloat64Map returns the value of the variable named by the key as a
+// map of float64s, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvFloat64Map(key string, defaultValue map[string]float64) (
+	map[string]float64, error,
+) {
+	return GetEnvMapAsFrom(e, key, defaultValue)
+}
+
+// GetEnvBoolMap returns the value of the variable named by the key as a map
+// of bools, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvBoolMap(key string, defaultValue map[string]bool) (
+	map[string]bool, error,
+) {
+	return GetEnvMapAsFrom(e, key, defaultValue)
+}
+
+// GetEnvDurationMap returns the value of the variable named by the key as a
+// map of durations, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvDurationMap(
+	key string, defaultValue map[string]time.Duration,
+) (map[string]time.Duration, error) {
+	return GetEnvMapAsFrom(e, key, defaultValue)
+}
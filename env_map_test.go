@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseEnvMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    MapOptions
+		want    map[string]string
+		wantErr error
+	}{
+		{"empty", "", MapOptions{}, map[string]string{}, nil},
+		{
+			"labels", "team=core, env = prod", MapOptions{},
+			map[string]string{"team": "core", "env": "prod"}, nil,
+		},
+		{
+			"routes", "/a:svc1;/b:svc2",
+			MapOptions{Entries: CsvOptions{Separator: ';'}, Separator: ':'},
+			map[string]string{"/a": "svc1", "/b": "svc2"}, nil,
+		},
+		{
+			"value with separator", "a=b=c", MapOptions{},
+			map[string]string{"a": "b=c"}, nil,
+		},
+		{
+			"quoted entry", `"a=b,c",d=e`, MapOptions{},
+			map[string]string{"a": "b,c", "d": "e"}, nil,
+		},
+		{
+			"no value", "a,b=", MapOptions{},
+			map[string]string{"a": "", "b": ""}, nil,
+		},
+		{"no key", "=a", MapOptions{}, nil, ErrEnvInvalidMapKey},
+		{"duplicate error", "a=1,a=2", MapOptions{}, nil, ErrEnvDuplicateKey},
+		{
+			"duplicate first", "a=1,a=2",
+			MapOptions{Duplicates: DuplicateKeyFirst},
+			map[string]string{"a": "1"}, nil,
+		},
+		{
+			"duplicate last", "a=1,a=2",
+			MapOptions{Duplicates: DuplicateKeyLast},
+			map[string]string{"a": "2"}, nil,
+		},
+		{"malformed", `"a`, MapOptions{}, nil, ErrCsvUnterminatedQuote},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := ParseEnvMap(tt.input, tt.opts)
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func Test_GetEnvMap(t *testing.T) {
+	t.Setenv("TEST_ENV_MAP", "team=core,env=prod")
+	t.Setenv("TEST_ENV_MAP_EMPTY", "")
+	def := map[string]string{"a": "b"}
+	got, err := GetEnvMap("TEST_ENV_MAP", def)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"team": "core", "env": "prod"}, got)
+	got, err = GetEnvMap("TEST_ENV_MAP_EMPTY", def)
+	require.Nil(t, err)
+	require.Equal(t, def, got)
+	got, err = GetEnvMapWith(
+		"TEST_ENV_MAP", nil, MapOptions{Entries: CsvOptions{Separator: ';'}},
+	)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"team": "core,env=prod"}, got)
+}
+
+func Test_GetEnvMap_typed(t *testing.T) {
+	t.Setenv("TEST_ENV_MAP_INT", "a=1,b=-2")
+	t.Setenv("TEST_ENV_MAP_UINT", "a=1")
+	t.Setenv("TEST_ENV_MAP_FLOAT", "a=1.5")
+	t.Setenv("TEST_ENV_MAP_BOOL", "a=true,b=0")
+	t.Setenv("TEST_ENV_MAP_DURATION", "a=1s,b=1m")
+	t.Setenv("TEST_ENV_MAP_INVALID", "a=1,b=x")
+	ints, err := GetEnvInt64Map("TEST_ENV_MAP_INT", nil)
+	require.Nil(t, err)
+	require.Equal(t, map[string]int64{"a": 1, "b": -2}, ints)
+	uints, err := GetEnvUint64Map("TEST_ENV_MAP_UINT", nil)
+	require.Nil(t, err)
+	require.Equal(t, map[string]uint64{"a": 1}, uints)
+	floats, err := GetEnvFloat64Map("TEST_ENV_MAP_FLOAT", nil)
+	require.Nil(t, err)
+	require.Equal(t, map[string]float64{"a": 1.5}, floats)
+	bools, err := GetEnvBoolMap("TEST_ENV_MAP_BOOL", nil)
+	require.Nil(t, err)
+	require.Equal(t, map[string]bool{"a": true, "b": false}, bools)
+	durations, err := GetEnvDurationMap("TEST_ENV_MAP_DURATION", nil)
+	require.Nil(t, err)
+	require.Equal(
+		t, map[string]time.Duration{"a": time.Second, "b": time.Minute},
+		durations,
+	)
+	def := map[string]int64{"x": 1}
+	ints, err = GetEnvInt64Map("TEST_ENV_MAP_MISSING", def)
+	require.Nil(t, err)
+	require.Equal(t, def, ints)
+	_, err = GetEnvInt64Map("TEST_ENV_MAP_INVALID", nil)
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	require.ErrorContains(t, err, `key "b"`)
+	_, err = GetEnvMapAs[int8]("TEST_ENV_MAP_INT", nil)
+	require.Nil(t, err)
+}
+
+func Test_Env_Map_options(t *testing.T) {
+	env := &Env{
+		Source: MapEnv{"ROUTES": "/a:svc1;/b:svc2;/a:svc3"},
+		Map: MapOptions{
+			Entries:    CsvOptions{Separator: ';'},
+			Separator:  ':',
+			Duplicates: DuplicateKeyLast,
+		},
+	}
+	got, err := env.GetEnvMap("ROUTES", nil)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"/a": "svc3", "/b": "svc2"}, got)
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"encoding"
+	"errors"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrEnvUnsupportedType = errors.New("unsupported type")
+
+var (
+	envParsersMu sync.RWMutex
+	envParsers   = map[reflect.Type]func(string) (any, error){}
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func init() {
+	RegisterEnvParser(time.ParseDuration)
+	RegisterEnvParser(url.Parse)
+}
+
+// RegisterEnvParser registers a function parsing environment variable values
+// into type `T`. Registered parsers take precedence over built-in ones, and
+// replace any parser previously registered for the same type.
+func RegisterEnvParser[T any](fn func(string) (T, error)) {
+	envParsersMu.Lock()
+	defer envParsersMu.Unlock()
+	envParsers[reflect.TypeFor[T]()] = func(s string) (any, error) {
+		return fn(s)
+	}
+}
+
+// ParseEnvValue parses the string into type `T`. Types are parsed using, in
+// order of precedence:
+//
+//   - parsers registered by `RegisterEnvParser()`;
+//   - `encoding.TextUnmarshaler` implemented by `T` or `*T`;
+//   - strings, booleans, integers, floats and complex numbers, including
+//     types derived from them;
+//   - pointers to any of the above.
+func ParseEnvValue[T any](s string) (T, error) {
+	return parseEnvValueAs[T](s, 0)
+}
+
+// parseEnvValueAs is the same as `ParseEnvValue()`, parsing booleans and
+// integers using the mode.
+func parseEnvValueAs[T any](s string, mode EnvParseMode) (T, error) {
+	var ret T
+	rv, err := parseEnvValue(s, reflect.TypeFor[T](), mode)
+	if err != nil {
+		return ret, err
+	}
+	// a nil interface value can't be asserted, leave the zero value then
+	ret, _ = rv.Interface().(T)
+	return ret, nil
+}
+
+// GetEnvAs returns the value of the environment variable named by the key
+// parsed as `T`. It is guaranteed to return the default value if the
+// environment variable is not found or empty. See `ParseEnvValue()` for
+// supported types.
+func GetEnvAs[T any](key string, defaultValue T) (T, error) {
+	return GetEnvAsFrom(DefaultEnv, key, defaultValue)
+}
+
+// GetEnvAsFrom is the same as `GetEnvAs()`, reading from the given `Env`.
+func GetEnvAsFrom[T any](e *Env, key string, defaultValue T) (T, error) {
+	ret, ok, err := parseEnvScalar(
+		e, key, reflect.TypeFor[T]().String(),
+		func(s string) (T, error) { return parseEnvValueAs[T](s, e.Parse) },
+	)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvSliceAs returns the value of the environment variable named by the
+// key as a slice of `T`. It is guaranteed to return the default value if the
+// environment variable is not found or empty. See `ParseEnvValue()` for
+// supported types.
+func GetEnvSliceAs[T any](key string, defaultValue []T) ([]T, error) {
+	return GetEnvSliceAsFrom(DefaultEnv, key, defaultValue)
+}
+
+// GetEnvSliceAsFrom is the same as `GetEnvSliceAs()`, reading from the given
+// `Env`.
+func GetEnvSliceAsFrom[T any](e *Env, key string, defaultValue []T) (
+	[]T, error,
+) {
+	vs, err := parseEnvCsv(
+		e, key, reflect.TypeFor[[]T]().String(),
+		func(s string) (T, error) { return parseEnvValueAs[T](s, e.Parse) },
+	)
+	if err != nil {
+		return nil, err
+	}
+	if nil == vs {
+		return defaultValue, nil
+	}
+	return vs, nil
+}
+
+func lookupEnvParser(t reflect.Type) func(string) (any, error) {
+	envParsersMu.RLock()
+	defer envParsersMu.RUnlock()
+	return envParsers[t]
+}
+
+// isEnvScalar reports whether the type is parsed as a whole, even if it is a
+// slice, such as `net.IP`.
+func isEnvScalar(t reflect.Type) bool {
+	return nil != lookupEnvParser(t) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func parseEnvValue(s string, t reflect.Type, mode EnvParseMode) (
+	reflect.Value, error,
+) {
+	if fn := lookupEnvParser(t); nil != fn {
+		v, err := fn(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if nil == v {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(v), nil
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		rv := reflect.New(t)
+		err := rv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return rv.Elem(), nil
+	}
+	if reflect.Pointer == t.Kind() {
+		ev, err := parseEnvValue(s, t.Elem(), mode)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv := reflect.New(t.Elem())
+		rv.Elem().Set(ev)
+		return rv, nil
+	}
+	return parseEnvKind(s, t, mode)
+}
+
+// parseEnvKind parses the string according to the kind of the type.
+func parseEnvKind(s string, t reflect.Type, mode EnvParseMode) (
+	reflect.Value, error,
+) {
+	rv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := ParseEnvBool(s, mode)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		i, err := ParseEnvInt(s, t.Bits(), mode)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		u, err := ParseEnvUint(s, t.Bits(), mode)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetFloat(f)
+	case reflect.Complex64, reflect.Complex128:
+		c, err := strconv.ParseComplex(s, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetComplex(c)
+	default:
+		return reflect.Value{}, ErrEnvUnsupportedType
+	}
+	return rv, nil
+}
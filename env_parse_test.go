@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type testEnvLevel int
+
+type testEnvPoint struct{ X, Y int }
+
+type testEnvShape interface{ Area() int }
+
+func Test_ParseEnvValue_builtin_kinds(t *testing.T) {
+	s, err := ParseEnvValue[string]("abc")
+	require.Nil(t, err)
+	require.Equal(t, "abc", s)
+	b, err := ParseEnvValue[bool]("true")
+	require.Nil(t, err)
+	require.True(t, b)
+	i, err := ParseEnvValue[int16]("-123")
+	require.Nil(t, err)
+	require.Equal(t, int16(-123), i)
+	u, err := ParseEnvValue[uint]("123")
+	require.Nil(t, err)
+	require.Equal(t, uint(123), u)
+	f, err := ParseEnvValue[float32]("1.5")
+	require.Nil(t, err)
+	require.Equal(t, float32(1.5), f)
+	c, err := ParseEnvValue[complex128]("1+2i")
+	require.Nil(t, err)
+	require.Equal(t, complex(1, 2), c)
+	l, err := ParseEnvValue[testEnvLevel]("3")
+	require.Nil(t, err)
+	require.Equal(t, testEnvLevel(3), l)
+	p, err := ParseEnvValue[*int]("3")
+	require.Nil(t, err)
+	require.Equal(t, 3, *p)
+}
+
+func Test_ParseEnvValue_returns_error(t *testing.T) {
+	_, err := ParseEnvValue[bool]("abc")
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	_, err = ParseEnvValue[int8]("1234")
+	require.ErrorIs(t, err, strconv.ErrRange)
+	_, err = ParseEnvValue[uint8]("-1")
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	_, err = ParseEnvValue[float64]("abc")
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	_, err = ParseEnvValue[complex64]("abc")
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	_, err = ParseEnvValue[*int]("abc")
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	_, err = ParseEnvValue[testEnvPoint]("1,2")
+	require.ErrorIs(t, err, ErrEnvUnsupportedType)
+}
+
+func Test_ParseEnvValue_text_unmarshaler(t *testing.T) {
+	id := "0191a8f2-6f1f-7e6b-8c0e-3c3f1f7a2b4d"
+	u, err := ParseEnvValue[Uuid](id)
+	require.Nil(t, err)
+	require.Equal(t, uuid.MustParse(id), u.Get())
+	up, err := ParseEnvValue[*Uuid](id)
+	require.Nil(t, err)
+	require.Equal(t, uuid.MustParse(id), up.Get())
+	_, err = ParseEnvValue[Uuid]("abc")
+	require.NotNil(t, err)
+}
+
+func Test_ParseEnvValue_registered_parsers(t *testing.T) {
+	d, err := ParseEnvValue[time.Duration]("1m30s")
+	require.Nil(t, err)
+	require.Equal(t, 90*time.Second, d)
+	u, err := ParseEnvValue[*url.URL]("https://example.com/a")
+	require.Nil(t, err)
+	require.Equal(t, "example.com", u.Host)
+	_, err = ParseEnvValue[*url.URL](":")
+	require.NotNil(t, err)
+
+	RegisterEnvParser(
+		func(s string) (testEnvPoint, error) {
+			x, y, ok := strings.Cut(s, ",")
+			if !ok {
+				return testEnvPoint{}, errors.New("invalid point")
+			}
+			p := testEnvPoint{}
+			p.X, _ = strconv.Atoi(x)
+			p.Y, _ = strconv.Atoi(y)
+			return p, nil
+		},
+	)
+	RegisterEnvParser(
+		func(s string) (*testEnvPoint, error) { return nil, nil },
+	)
+	p, err := ParseEnvValue[testEnvPoint]("1,2")
+	require.Nil(t, err)
+	require.Equal(t, testEnvPoint{1, 2}, p)
+	_, err = ParseEnvValue[testEnvPoint]("1")
+	require.EqualError(t, err, "invalid point")
+	pp, err := ParseEnvValue[*testEnvPoint]("1,2")
+	require.Nil(t, err)
+	require.Nil(t, pp)
+}
+
+func Test_ParseEnvValue_registered_interface_returns_nil(t *testing.T) {
+	RegisterEnvParser(
+		func(s string) (testEnvShape, error) { return nil, nil },
+	)
+	v, err := ParseEnvValue[testEnvShape]("x")
+	require.Nil(t, err)
+	require.Nil(t, v)
+	env := NewEnv(MapEnv{"SHAPE": "x"})
+	v, err = GetEnvAsFrom[testEnvShape](env, "SHAPE", nil)
+	require.Nil(t, err)
+	require.Nil(t, v)
+}
+
+func Test_GetEnvAs(t *testing.T) {
+	t.Setenv("TEST_ENV_AS", "15s")
+	t.Setenv("TEST_ENV_AS_EMPTY", "")
+	d, err := GetEnvAs[time.Duration]("TEST_ENV_AS", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, 15*time.Second, d)
+	d, err = GetEnvAs("TEST_ENV_AS_EMPTY", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, time.Second, d)
+	_, err = GetEnvAs("TEST_ENV_AS", 1)
+	require.NotNil(t, err)
+}
+
+func Test_GetEnvAsFrom_returns_lookup_error(t *testing.T) {
+	env := NewEnv(MapEnv{"A": "1", "A_FILE": "file"})
+	env.FileSecrets = true
+	_, err := GetEnvAsFrom(env, "A", 1)
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+	_, err = GetEnvSliceAsFrom(env, "A", []int{1})
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+}
+
+func Test_GetEnvSliceAs(t *testing.T) {
+	t.Setenv("TEST_ENV_SLICE_AS", "1s,,2m")
+	t.Setenv("TEST_ENV_SLICE_AS_EMPTY", "")
+	ds, err := GetEnvSliceAs[time.Duration]("TEST_ENV_SLICE_AS", nil)
+	require.Nil(t, err)
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Minute}, ds)
+	ds, err = GetEnvSliceAs(
+		"TEST_ENV_SLICE_AS_EMPTY", []time.Duration{time.Hour},
+	)
+	require.Nil(t, err)
+	require.Equal(t, []time.Duration{time.Hour}, ds)
+	is, err := GetEnvSliceAs[int]("TEST_ENV_SLICE_AS", nil)
+	require.NotNil(t, err)
+	require.Nil(t, is)
+}
+
+func Test_LoadEnv_uses_parsers(t *testing.T) {
+	t.Setenv("TEST_BIND_TIMEOUT", "1s")
+	t.Setenv("TEST_BIND_ID", "0191a8f2-6f1f-7e6b-8c0e-3c3f1f7a2b4d")
+	t.Setenv("TEST_BIND_URLS", "http://a,http://b")
+	var cfg struct {
+		Timeout time.Duration `env:"TEST_BIND_TIMEOUT"`
+		Id      *Uuid         `env:"TEST_BIND_ID"`
+		Urls    []*url.URL    `env:"TEST_BIND_URLS"`
+	}
+	require.Nil(t, LoadEnv(&cfg))
+	require.Equal(t, time.Second, cfg.Timeout)
+	require.Equal(t, "0191a8f2-6f1f-7e6b-8c0e-3c3f1f7a2b4d", cfg.Id.Get().String())
+	require.Len(t, cfg.Urls, 2)
+	require.Equal(t, "b", cfg.Urls[1].Host)
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrEnvOutOfRange     = errors.New("value out of range")
+	ErrEnvNotAllowed     = errors.New("value not allowed")
+	ErrEnvPatternNoMatch = errors.New("value does not match pattern")
+	ErrEnvLength         = errors.New("invalid length")
+)
+
+// EnvRule checks a value read from environment variables, returning an error
+// describing why the value is invalid.
+type EnvRule[T any] func(T) error
+
+// EnvMin requires the value to be greater than or equal to `min`.
+func EnvMin[T cmp.Ordered](min T) EnvRule[T] {
+	return func(v T) error {
+		if v < min {
+			return fmt.Errorf("%w: %v is less than %v", ErrEnvOutOfRange, v, min)
+		}
+		return nil
+	}
+}
+
+// EnvMax requires the value to be less than or equal to `max`.
+func EnvMax[T cmp.Ordered](max T) EnvRule[T] {
+	return func(v T) error {
+		if v > max {
+			return fmt.Errorf(
+				"%w: %v is greater than %v", ErrEnvOutOfRange, v, max,
+			)
+		}
+		return nil
+	}
+}
+
+// EnvRange requires the value to be within `min` and `max`, inclusive.
+func EnvRange[T cmp.Ordered](min, max T) EnvRule[T] {
+	return func(v T) error {
+		if v < min || v > max {
+			return fmt.Errorf(
+				"%w: %v is not in [%v, %v]", ErrEnvOutOfRange, v, min, max,
+			)
+		}
+		return nil
+	}
+}
+
+// EnvOneOf requires the value to be one of the given values.
+func EnvOneOf[T comparable](values ...T) EnvRule[T] {
+	return func(v T) error {
+		if slices.Contains(values, v) {
+			return nil
+		}
+		return fmt.Errorf(
+			"%w: %v is not one of %s", ErrEnvNotAllowed, v,
+			strings.Join(
+				SliceMapFunc[[]string](
+					values, func(a T) string { return fmt.Sprint(a) },
+				), ", ",
+			),
+		)
+	}
+}
+
+// EnvPattern requires the whole value to match the regular expression. It
+// panics if the expression cannot be compiled.
+func EnvPattern[T ~string](expr string) EnvRule[T] {
+	re := regexp.MustCompile(`^(?:` + expr + `)$`)
+	return func(v T) error {
+		if re.MatchString(string(v)) {
+			return nil
+		}
+		return fmt.Errorf("%w %s", ErrEnvPatternNoMatch, expr)
+	}
+}
+
+// EnvLen requires the number of characters of the value to be within `min`
+// and `max`, inclusive. A negative `max` means no upper bound.
+func EnvLen[T ~string](min, max int) EnvRule[T] {
+	return func(v T) error {
+		return checkEnvLen(len([]rune(string(v))), min, max)
+	}
+}
+
+// EnvCount requires the number of items of the list to be within `min` and
+// `max`, inclusive. A negative `max` means no upper bound.
+func EnvCount[T any](min, max int) EnvRule[[]T] {
+	return func(v []T) error {
+		return checkEnvLen(len(v), min, max)
+	}
+}
+
+// EnvNonEmpty requires the list to have at least one item.
+func EnvNonEmpty[T any]() EnvRule[[]T] {
+	return EnvCount[T](1, -1)
+}
+
+// EnvEach applies the rules to every item of the list.
+func EnvEach[T any](rules ...EnvRule[T]) EnvRule[[]T] {
+	return func(v []T) error {
+		for i, item := range v {
+			if err := checkEnvRules(item, rules); err != nil {
+				return &envItemError{i, err}
+			}
+		}
+		return nil
+	}
+}
+
+// GetEnvAsChecked is the same as `GetEnvAs()`, checking the value against the
+// rules. Problems are reported as `*EnvError`. Default values are not checked.
+func GetEnvAsChecked[T any](
+	key string, defaultValue T, rules ...EnvRule[T],
+) (T, error) {
+	return GetEnvAsCheckedFrom(DefaultEnv, key, defaultValue, rules...)
+}
+
+// GetEnvAsCheckedFrom is the same as `GetEnvAsChecked()`, reading from the
+// given `Env`.
+func GetEnvAsCheckedFrom[T any](
+	e *Env, key string, defaultValue T, rules ...EnvRule[T],
+) (T, error) {
+	ret, ok, err := parseEnvScalar(
+		e, key, reflect.TypeFor[T]().String(), func(s string) (T, error) {
+			v, err := parseEnvValueAs[T](s, e.Parse)
+			if nil == err {
+				err = checkEnvRules(v, rules)
+			}
+			return v, err
+		},
+	)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if !ok {
+		return defaultValue, nil
+	}
+	return ret, nil
+}
+
+// GetEnvSliceAsChecked is the same as `GetEnvSliceAs()`, checking the list
+// against the rules. Use `EnvEach()` to check every item. Problems are
+// reported as `*EnvError`. Default values are not checked.
+func GetEnvSliceAsChecked[T any](
+	key string, defaultValue []T, rules ...EnvRule[[]T],
+) ([]T, error) {
+	return GetEnvSliceAsCheckedFrom(DefaultEnv, key, defaultValue, rules...)
+}
+
+// GetEnvSliceAsCheckedFrom is the same as `GetEnvSliceAsChecked()`, reading
+// from the given `Env`.
+func GetEnvSliceAsCheckedFrom[T any](
+	e *Env, key string, defaultValue []T, rules ...EnvRule[[]T],
+) ([]T, error) {
+	ret, err := GetEnvSliceAsFrom[T](e, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if nil == ret {
+		return defaultValue, nil
+	}
+	return CheckEnvFrom(e, key, rules...)(ret, nil)
+}
+
+// CheckEnv checks the value returned by a getter against the rules, so rules
+// can be used with any getter:
+//
+//	port, err := CheckEnv("PORT", EnvMin[uint16](1))(GetEnvUint16("PORT", 80))
+//
+// Errors returned by the getter are passed through. Unlike the `*Checked`
+// getters, default values are checked too.
+func CheckEnv[T any](key string, rules ...EnvRule[T]) func(T, error) (
+	T, error,
+) {
+	return CheckEnvFrom(DefaultEnv, key, rules...)
+}
+
+// CheckEnvFrom is the same as `CheckEnv()`, reporting the value read from the
+// given `Env`.
+func CheckEnvFrom[T any](e *Env, key string, rules ...EnvRule[T]) func(
+	T, error,
+) (T, error) {
+	return func(v T, err error) (T, error) {
+		if err != nil {
+			return v, err
+		}
+		if err = checkEnvRules(v, rules); err != nil {
+			var zero T
+			typ := reflect.TypeFor[T]().String()
+			return zero, e.envError(key, e.peek(key), typ, err)
+		}
+		return v, nil
+	}
+}
+
+func checkEnvRules[T any](v T, rules []EnvRule[T]) error {
+	for _, rule := range rules {
+		if err := rule(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkEnvLen(n, min, max int) error {
+	if n < min {
+		return fmt.Errorf("%w: %d is less than %d", ErrEnvLength, n, min)
+	}
+	if max >= 0 && n > max {
+		return fmt.Errorf("%w: %d is greater than %d", ErrEnvLength, n, max)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+		msg     string
+	}{
+		{"min ok", EnvMin(1)(1), nil, ""},
+		{
+			"min", EnvMin(1)(0), ErrEnvOutOfRange,
+			"value out of range: 0 is less than 1",
+		},
+		{"max ok", EnvMax(1.5)(1.5), nil, ""},
+		{
+			"max", EnvMax(1.5)(2), ErrEnvOutOfRange,
+			"value out of range: 2 is greater than 1.5",
+		},
+		{"range ok", EnvRange[uint16](1, 65535)(80), nil, ""},
+		{
+			"range", EnvRange[uint16](1, 65535)(0), ErrEnvOutOfRange,
+			"value out of range: 0 is not in [1, 65535]",
+		},
+		{"one of ok", EnvOneOf("json", "text")("json"), nil, ""},
+		{
+			"one of", EnvOneOf("json", "text")("xml"), ErrEnvNotAllowed,
+			"value not allowed: xml is not one of json, text",
+		},
+		{"pattern ok", EnvPattern[string](`[a-z]+`)("abc"), nil, ""},
+		{
+			"pattern whole value", EnvPattern[string](`[a-z]+`)("abc1"),
+			ErrEnvPatternNoMatch, "value does not match pattern [a-z]+",
+		},
+		{"len ok", EnvLen[string](1, 3)("héé"), nil, ""},
+		{
+			"len min", EnvLen[string](1, 3)(""), ErrEnvLength,
+			"invalid length: 0 is less than 1",
+		},
+		{
+			"len max", EnvLen[string](1, 3)("abcd"), ErrEnvLength,
+			"invalid length: 4 is greater than 3",
+		},
+		{"len unbounded", EnvLen[string](0, -1)("abcd"), nil, ""},
+		{"count ok", EnvCount[int](1, 2)([]int{1, 2}), nil, ""},
+		{
+			"count", EnvCount[int](1, 2)([]int{1, 2, 3}), ErrEnvLength,
+			"invalid length: 3 is greater than 2",
+		},
+		{"non-empty ok", EnvNonEmpty[int]()([]int{1}), nil, ""},
+		{
+			"non-empty", EnvNonEmpty[int]()(nil), ErrEnvLength,
+			"invalid length: 0 is less than 1",
+		},
+		{"each ok", EnvEach(EnvMin(1))([]int{1, 2}), nil, ""},
+		{
+			"each", EnvEach(EnvMin(1), EnvMax(5))([]int{1, 6}),
+			ErrEnvOutOfRange, "item 1: value out of range: 6 is greater than 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				require.ErrorIs(t, tt.err, tt.wantErr)
+				if nil != tt.wantErr {
+					require.EqualError(t, tt.err, tt.msg)
+				}
+			},
+		)
+	}
+}
+
+func Test_GetEnvAsChecked(t *testing.T) {
+	t.Setenv("TEST_RULES_PORT", "8080")
+	t.Setenv("TEST_RULES_PORT_ZERO", "0")
+	t.Setenv("TEST_RULES_FORMAT", "xml")
+	got, err := GetEnvAsChecked("TEST_RULES_PORT", 80, EnvRange(1, 65535))
+	require.Nil(t, err)
+	require.Equal(t, 8080, got)
+	got, err = GetEnvAsChecked("TEST_RULES_PORT_MISSING", 0, EnvMin(1))
+	require.Nil(t, err)
+	require.Equal(t, 0, got)
+	_, err = GetEnvAsChecked("TEST_RULES_PORT_ZERO", 80, EnvRange(1, 65535))
+	require.ErrorIs(t, err, ErrEnvOutOfRange)
+	require.EqualError(
+		t, err, `invalid environment variable TEST_RULES_PORT_ZERO (int) `+
+			`value "0": value out of range: 0 is not in [1, 65535]`,
+	)
+	_, err = GetEnvAsChecked(
+		"TEST_RULES_FORMAT", "json", EnvOneOf("json", "text"),
+	)
+	var ee *EnvError
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "TEST_RULES_FORMAT", ee.Key)
+	require.Equal(t, "xml", ee.Value)
+	_, err = GetEnvAsChecked[uint8]("TEST_RULES_PORT", 0)
+	require.ErrorIs(t, err, strconv.ErrRange)
+	require.ErrorAs(t, err, &ee)
+}
+
+func Test_GetEnvSliceAsChecked(t *testing.T) {
+	t.Setenv("TEST_RULES_PORTS", "80,0")
+	t.Setenv("TEST_RULES_PORTS_EMPTY", ",")
+	_, err := GetEnvSliceAsChecked(
+		"TEST_RULES_PORTS", nil, EnvEach(EnvMin[uint16](1)),
+	)
+	require.ErrorIs(t, err, ErrEnvOutOfRange)
+	require.ErrorContains(t, err, "TEST_RULES_PORTS ([]uint16)")
+	require.ErrorContains(t, err, "item 1")
+	got, err := GetEnvSliceAsChecked(
+		"TEST_RULES_PORTS", nil, EnvCount[uint16](1, 2),
+	)
+	require.Nil(t, err)
+	require.Equal(t, []uint16{80, 0}, got)
+	_, err = GetEnvSliceAsChecked(
+		"TEST_RULES_PORTS_EMPTY", nil, EnvNonEmpty[uint16](),
+	)
+	require.ErrorIs(t, err, ErrEnvLength)
+	def := []uint16{1}
+	got, err = GetEnvSliceAsChecked(
+		"TEST_RULES_PORTS_MISSING", def, EnvCount[uint16](2, 2),
+	)
+	require.Nil(t, err)
+	require.Equal(t, def, got)
+}
+
+func Test_CheckEnv(t *testing.T) {
+	t.Setenv("TEST_RULES_PORT", "0")
+	t.Setenv("TEST_RULES_PORTS", "80;443")
+	_, err := CheckEnv("TEST_RULES_PORT", EnvMin[uint16](1))(
+		GetEnvUint16("TEST_RULES_PORT", 80),
+	)
+	require.ErrorIs(t, err, ErrEnvOutOfRange)
+	require.ErrorContains(t, err, `TEST_RULES_PORT (uint16) value "0"`)
+	_, err = CheckEnv("TEST_RULES_PORT_MISSING", EnvMin[uint16](1))(
+		GetEnvUint16("TEST_RULES_PORT_MISSING", 0),
+	)
+	require.ErrorIs(t, err, ErrEnvOutOfRange)
+	_, err = CheckEnv[[]uint16]("TEST_RULES_PORTS", EnvNonEmpty[uint16]())(
+		GetEnvUint16Csv("TEST_RULES_PORTS", nil),
+	)
+	require.ErrorIs(t, err, strconv.ErrSyntax)
+	env := &Env{Source: MapEnv{"APP_PORTS": "80;443"}}
+	env.Csv.Separator = ';'
+	app := env.Scope("APP_")
+	got, err := CheckEnvFrom(app, "PORTS", EnvEach(EnvMax[uint16](1024)))(
+		app.GetEnvUint16Csv("PORTS", nil),
+	)
+	require.Nil(t, err)
+	require.Equal(t, []uint16{80, 443}, got)
+	_, err = CheckEnvFrom(app, "PORTS", EnvEach(EnvMax[uint16](100)))(
+		app.GetEnvUint16Csv("PORTS", nil),
+	)
+	var ee *EnvError
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "APP_PORTS", ee.Key)
+	require.Equal(t, "80;443", ee.Value)
+}
+
+func Test_CheckEnvFrom_reports_value_read_by_getter(t *testing.T) {
+	file := writeTestFile(t, t.TempDir(), "port", "0")
+	env := NewEnv(MapEnv{
+		"PORT_FILE": file, "BASE": "7", "OFFSET": "${BASE}0",
+	})
+	env.FileSecrets = true
+	env.Expand = true
+	_, err := CheckEnvFrom(env, "PORT", EnvMin[uint16](1))(
+		env.GetEnvUint16("PORT", 80),
+	)
+	require.ErrorIs(t, err, ErrEnvOutOfRange)
+	require.ErrorContains(t, err, `PORT (uint16) value "0"`)
+	_, err = CheckEnvFrom(env, "OFFSET", EnvMax[uint16](10))(
+		env.GetEnvUint16("OFFSET", 0),
+	)
+	require.ErrorIs(t, err, ErrEnvOutOfRange)
+	require.ErrorContains(t, err, `OFFSET (uint16) value "70"`)
+}
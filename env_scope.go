@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+)
+
+// EnvScope returns an `Env` reading variables of the process environment
+// whose names start with the prefix. For example, `EnvScope("BILLING_")`
+// reads `BILLING_HOST` by `GetEnv("HOST", "", false)`.
+func EnvScope(prefix string) *Env {
+	return DefaultEnv.Scope(prefix)
+}
+
+// Scope returns a copy of the `Env` reading variables whose names start with
+// the prefix. Scopes can be nested, `e.Scope("APP_").Scope("DB_")` reads
+// variables starting with `APP_DB_`.
+func (e *Env) Scope(prefix string) *Env {
+	scope := *e
+	scope.Prefix = e.Prefix + prefix
+	return &scope
+}
+
+// Keys returns the sorted names of all variables under the scope, without the
+// prefix. Variables are not recorded in `Usage`.
+func (e *Env) Keys() []string {
+	var keys []string
+	for _, key := range e.Source.Keys() {
+		if name, ok := strings.CutPrefix(key, e.Prefix); ok && "" != name {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// Vars returns all variables under the scope, keyed by names without the
+// prefix, such as for dumping the configuration. Values are read by
+// `Lookup()`, but variables are not recorded in `Usage`, so `FindUnknown()`
+// still reports them. Values of variables declared `Sensitive`, and values
+// holding decrypted data, including expanded references, are redacted.
+func (e *Env) Vars() (map[string]string, error) {
+	src := *e
+	src.Usage = nil
+	keys := e.Keys()
+	vars := make(map[string]string, len(keys))
+	for _, key := range keys {
+		val, _, decrypted, err := src.lookupScoped(key)
+		if err != nil {
+			return nil, err
+		}
+		if decrypted || e.isSensitive(key) {
+			val = redactedEnvValue
+		}
+		vars[key] = val
+	}
+	return vars, nil
+}
+
+// isSensitive reports whether the variable named by the key, under the scope,
+// is declared `Sensitive`.
+func (e *Env) isSensitive(key string) bool {
+	if nil == e.Declared {
+		return false
+	}
+	v, ok := e.Declared.Get(e.Prefix + key)
+	return ok && v.Sensitive
+}
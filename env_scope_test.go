@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvScope(t *testing.T) {
+	t.Setenv("TEST_SCOPE_BILLING_HOST", "billing")
+	t.Setenv("TEST_SCOPE_BILLING_PORT", "8080")
+	t.Setenv("TEST_SCOPE_HOST", "other")
+	scope := EnvScope("TEST_SCOPE_BILLING_")
+	require.Equal(t, "billing", scope.GetEnv("HOST", "", false))
+	require.Equal(t, "billing", scope.MustGetEnv("HOST"))
+	port, err := scope.GetEnvUint16("PORT", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint16(8080), port)
+	require.Equal(t, "d", scope.GetEnvWithDefault("MISSING", "d"))
+	require.Panics(t, func() { scope.MustGetEnv("MISSING") })
+	require.Empty(t, DefaultEnv.Prefix)
+}
+
+func Test_Env_Scope_nested(t *testing.T) {
+	env := NewEnv(MapEnv{"APP_DB_HOST": "db", "APP_HOST": "app"})
+	app := env.Scope("APP_")
+	db := app.Scope("DB_")
+	require.Equal(t, "APP_DB_", db.Prefix)
+	require.Equal(t, "app", app.GetEnv("HOST", "", false))
+	require.Equal(t, "db", db.GetEnv("HOST", "", false))
+	require.Equal(t, "APP_", app.Prefix)
+	require.Empty(t, env.Prefix)
+}
+
+func Test_Env_Scope_keeps_options(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secret")
+	require.Nil(t, os.WriteFile(file, []byte("s3cret\n"), 0600))
+	env := &Env{
+		Source: MapEnv{
+			"APP_PASSWORD_FILE": file,
+			"APP_PORTS":         "80;443",
+		},
+		FileSecrets: true,
+		Csv:         CsvOptions{Separator: ';'},
+	}
+	app := env.Scope("APP_")
+	require.Equal(t, "s3cret", app.MustGetEnv("PASSWORD"))
+	ports, err := app.GetEnvUint16Csv("PORTS", nil)
+	require.Nil(t, err)
+	require.Equal(t, []uint16{80, 443}, ports)
+}
+
+func Test_Env_Scope_errors_have_full_key(t *testing.T) {
+	env := NewEnv(MapEnv{"APP_PORT": "x"})
+	c := env.Scope("APP_").NewChecker()
+	c.Int("PORT", 0, 16)
+	require.Equal(t, "APP_PORT", c.Errors()[0].Key)
+	require.Equal(t, "x", c.Errors()[0].Value)
+	var cfg struct {
+		Port int `env:"PORT"`
+	}
+	err := env.Scope("APP_").LoadEnv(&cfg)
+	var ee EnvErrors
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "APP_PORT", ee[0].Key)
+}
+
+func Test_Env_Keys(t *testing.T) {
+	env := NewEnv(
+		MapEnv{"APP_B": "2", "APP_A": "1", "APP_": "x", "OTHER": "3"},
+	)
+	require.Equal(t, []string{"A", "B"}, env.Scope("APP_").Keys())
+	require.Equal(
+		t, []string{"APP_", "APP_A", "APP_B", "OTHER"}, env.Keys(),
+	)
+	vars, err := env.Scope("APP_").Vars()
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"A": "1", "B": "2"}, vars)
+	require.Nil(t, env.Scope("NONE_").Keys())
+}
+
+func Test_Env_Vars_does_not_record_usage(t *testing.T) {
+	key, err := GenerateEnvKey()
+	require.Nil(t, err)
+	sealed, err := SealEnv(key, "APP_TOKEN", "s3cret", EnvXChaCha20Poly1305)
+	require.Nil(t, err)
+	env := NewEnv(
+		MapEnv{
+			"APP_HOST": "h", "APP_PASSWORD": "p", "APP_TOKEN": sealed,
+			"APP_TYPO": "x",
+		},
+	)
+	env.MasterKey = key
+	app := env.Scope("APP_")
+	app.Declare(
+		EnvVar{Name: "HOST"}, EnvVar{Name: "PASSWORD", Sensitive: true},
+	)
+	require.Equal(t, "h", app.GetEnvWithDefault("HOST", ""))
+	vars, err := app.Vars()
+	require.Nil(t, err)
+	require.Equal(
+		t, map[string]string{
+			"HOST": "h", "PASSWORD": "***", "TOKEN": "***", "TYPO": "x",
+		}, vars,
+	)
+	var unknown []string
+	for _, u := range env.FindUnknown("APP_") {
+		unknown = append(unknown, u.Key)
+	}
+	require.Equal(
+		t, []string{"APP_PASSWORD", "APP_TOKEN", "APP_TYPO"}, unknown,
+	)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+var (
+	_ EnvSource = OsEnv{}
+	_ EnvSource = MapEnv{}
+	_ EnvSource = ChainEnv{}
+)
+
+// EnvSource provides values of environment variables.
+type EnvSource interface {
+	// LookupEnv retrieves the value of the variable named by the key. The
+	// returned boolean is `false` if the variable is not present.
+	LookupEnv(key string) (string, bool)
+	// Keys returns the sorted names of all variables present in the source.
+	Keys() []string
+}
+
+// OsEnv reads variables from the process environment.
+type OsEnv struct{}
+
+func (OsEnv) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (OsEnv) Keys() []string {
+	env := os.Environ()
+	keys := make([]string, 0, len(env))
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		// Windows has special variables like `=C:`
+		if "" != key {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// MapEnv reads variables from an in-memory map.
+type MapEnv map[string]string
+
+func (m MapEnv) LookupEnv(key string) (string, bool) {
+	val, found := m[key]
+	return val, found
+}
+
+func (m MapEnv) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ChainEnv reads variables from a list of sources. Sources are consulted in
+// order, the first source having the variable wins.
+type ChainEnv []EnvSource
+
+func (c ChainEnv) LookupEnv(key string) (string, bool) {
+	for _, src := range c {
+		if val, found := src.LookupEnv(key); found {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+func (c ChainEnv) Keys() []string {
+	var keys []string
+	for _, src := range c {
+		keys = append(keys, src.Keys()...)
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
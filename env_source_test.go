@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OsEnv(t *testing.T) {
+	t.Setenv("TEST_SOURCE_OS", "os")
+	val, found := OsEnv{}.LookupEnv("TEST_SOURCE_OS")
+	require.True(t, found)
+	require.Equal(t, "os", val)
+	_, found = OsEnv{}.LookupEnv("TEST_SOURCE_MISSING")
+	require.False(t, found)
+	require.Contains(t, OsEnv{}.Keys(), "TEST_SOURCE_OS")
+}
+
+func Test_MapEnv(t *testing.T) {
+	t.Parallel()
+	src := MapEnv{"B": "b", "A": ""}
+	val, found := src.LookupEnv("A")
+	require.True(t, found)
+	require.Empty(t, val)
+	_, found = src.LookupEnv("C")
+	require.False(t, found)
+	require.Equal(t, []string{"A", "B"}, src.Keys())
+}
+
+func Test_ChainEnv(t *testing.T) {
+	t.Parallel()
+	src := ChainEnv{MapEnv{"A": "1", "B": ""}, MapEnv{"B": "2", "C": "3"}}
+	val, found := src.LookupEnv("B")
+	require.True(t, found)
+	require.Empty(t, val)
+	val, found = src.LookupEnv("C")
+	require.True(t, found)
+	require.Equal(t, "3", val)
+	_, found = src.LookupEnv("D")
+	require.False(t, found)
+	require.Equal(t, []string{"A", "B", "C"}, src.Keys())
+}
+
+func Test_Env_reads_from_source(t *testing.T) {
+	t.Parallel()
+	env := NewEnv(
+		MapEnv{
+			"STR":   "str",
+			"EMPTY": "",
+			"INT":   "-12",
+			"UINT":  "12",
+			"FLOAT": "1.5",
+			"BOOL":  "true",
+			"CSV":   "1,,2",
+			"BOOLS": "true,false",
+		},
+	)
+	require.Equal(t, "str", env.GetEnvWithDefault("STR", "def"))
+	require.Empty(t, env.GetEnvWithDefault("EMPTY", "def"))
+	require.Equal(t, "def", env.GetEnvWithDefaultNE("EMPTY", "def"))
+	require.Equal(t, "def", env.GetEnv("MISSING", "def", false))
+	require.Equal(t, "str", env.MustGetEnv("STR"))
+	require.Panics(t, func() { env.MustGetEnv("MISSING") })
+	require.Equal(t, "str", env.MustGetEnvNE("STR"))
+	require.Panics(t, func() { env.MustGetEnvNE("EMPTY") })
+	require.Equal(t, []string{"1", "2"}, env.GetEnvCsv("CSV", nil))
+	require.Equal(t, []string{"a"}, env.GetEnvCsv("EMPTY", []string{"a"}))
+
+	i8, err := env.GetEnvInt8("INT", 0)
+	require.Nil(t, err)
+	require.Equal(t, int8(-12), i8)
+	i16, err := env.GetEnvInt16("INT", 0)
+	require.Nil(t, err)
+	require.Equal(t, int16(-12), i16)
+	i32, err := env.GetEnvInt32("INT", 0)
+	require.Nil(t, err)
+	require.Equal(t, int32(-12), i32)
+	i64, err := env.GetEnvInt64("MISSING", 7)
+	require.Nil(t, err)
+	require.Equal(t, int64(7), i64)
+	_, err = env.GetEnvInt64("STR", 0)
+	require.NotNil(t, err)
+
+	u8, err := env.GetEnvUint8("UINT", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint8(12), u8)
+	u16, err := env.GetEnvUint16("UINT", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint16(12), u16)
+	u32, err := env.GetEnvUint32("UINT", 0)
+	require.Nil(t, err)
+	require.Equal(t, uint32(12), u32)
+	u64, err := env.GetEnvUint64("MISSING", 7)
+	require.Nil(t, err)
+	require.Equal(t, uint64(7), u64)
+	_, err = env.GetEnvUint64("INT", 0)
+	require.NotNil(t, err)
+
+	f32, err := env.GetEnvFloat32("FLOAT", 0)
+	require.Nil(t, err)
+	require.Equal(t, float32(1.5), f32)
+	f64, err := env.GetEnvFloat64("MISSING", 2.5)
+	require.Nil(t, err)
+	require.Equal(t, 2.5, f64)
+	_, err = env.GetEnvFloat64("STR", 0)
+	require.NotNil(t, err)
+
+	b, err := env.GetEnvBool("BOOL", false)
+	require.Nil(t, err)
+	require.True(t, b)
+	_, err = env.GetEnvBool("STR", false)
+	require.NotNil(t, err)
+
+	i8s, err := env.GetEnvInt8Csv("CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []int8{1, 2}, i8s)
+	i16s, err := env.GetEnvInt16Csv("CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []int16{1, 2}, i16s)
+	i32s, err := env.GetEnvInt32Csv("CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []int32{1, 2}, i32s)
+	i64s, err := env.GetEnvInt64Csv("MISSING", []int64{3})
+	require.Nil(t, err)
+	require.Equal(t, []int64{3}, i64s)
+	u8s, err := env.GetEnvUint8Csv("CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []uint8{1, 2}, u8s)
+	u16s, err := env.GetEnvUint16Csv("CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []uint16{1, 2}, u16s)
+	u32s, err := env.GetEnvUint32Csv("CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []uint32{1, 2}, u32s)
+	u64s, err := env.GetEnvUint64Csv("MISSING", []uint64{3})
+	require.Nil(t, err)
+	require.Equal(t, []uint64{3}, u64s)
+	f32s, err := env.GetEnvFloat32Csv("CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []float32{1, 2}, f32s)
+	f64s, err := env.GetEnvFloat64Csv("MISSING", []float64{3})
+	require.Nil(t, err)
+	require.Equal(t, []float64{3}, f64s)
+	bs, err := env.GetEnvBoolCsv("BOOLS", nil)
+	require.Nil(t, err)
+	require.Equal(t, []bool{true, false}, bs)
+	_, err = env.GetEnvBoolCsv("CSV", nil)
+	require.NotNil(t, err)
+}
+
+func Test_Env_LoadEnv(t *testing.T) {
+	t.Parallel()
+	env := NewEnv(MapEnv{"TEST_BIND_NAME": "map", "TEST_BIND_DB_PORT": "1"})
+	var cfg testEnvConfig
+	require.Nil(t, env.LoadEnv(&cfg))
+	require.Equal(t, "map", cfg.Name)
+	require.Equal(t, uint16(1), cfg.Db.Port)
+}
+
+func Test_Env_NewChecker(t *testing.T) {
+	t.Parallel()
+	env := NewEnv(MapEnv{"INT": "abc"})
+	c := env.NewChecker()
+	c.Int("INT", 0, 64)
+	c.Require("MISSING")
+	require.Len(t, c.Errors(), 2)
+	require.Equal(t, "abc", c.Errors()[0].Value)
+}
+
+func Test_Env_FileSecrets(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	require.Nil(t, os.WriteFile(secret, []byte("s3cret\r\n"), 0600))
+	number := filepath.Join(dir, "number")
+	require.Nil(t, os.WriteFile(number, []byte("42\n"), 0600))
+	env := NewEnv(
+		MapEnv{
+			"PASSWORD_FILE": secret,
+			"EMPTY":         "",
+			"EMPTY_FILE":    secret,
+			"NUMBER_FILE":   number,
+			"LIST_FILE":     number,
+			"BOTH":          "value",
+			"BOTH_FILE":     secret,
+			"BROKEN_FILE":   filepath.Join(dir, "missing"),
+			"PLAIN":         "plain",
+			"PLAIN_FILE":    "",
+		},
+	)
+	_, found, err := env.Lookup("PASSWORD")
+	require.Nil(t, err)
+	require.False(t, found)
+	env.FileSecrets = true
+	val, found, err := env.Lookup("PASSWORD")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, "s3cret", val)
+	require.Equal(t, "s3cret", env.MustGetEnv("PASSWORD"))
+	require.Equal(t, "s3cret", env.MustGetEnvNE("EMPTY"))
+	require.Equal(t, "plain", env.GetEnvWithDefault("PLAIN", ""))
+	i, err := env.GetEnvInt64("NUMBER", 0)
+	require.Nil(t, err)
+	require.Equal(t, int64(42), i)
+	is, err := env.GetEnvUint8Csv("LIST", nil)
+	require.Nil(t, err)
+	require.Equal(t, []uint8{42}, is)
+
+	_, _, err = env.Lookup("BOTH")
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+	_, err = env.GetEnvInt("BOTH", 1, 64)
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+	_, err = env.GetEnvUint("BOTH", 1, 64)
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+	_, err = env.GetEnvFloat("BOTH", 1, 64)
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+	_, err = env.GetEnvBool("BOTH", true)
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+	_, err = env.GetEnvBoolCsv("BOTH", nil)
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+	require.Panics(t, func() { env.GetEnvWithDefault("BOTH", "") })
+	require.Panics(t, func() { env.MustGetEnv("BOTH") })
+	require.Panics(t, func() { env.MustGetEnvNE("BOTH") })
+	require.Panics(t, func() { env.GetEnvCsv("BOTH", nil) })
+
+	_, _, err = env.Lookup("BROKEN")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	var ee *EnvError
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "BROKEN_FILE", ee.Key)
+
+	c := env.NewChecker()
+	c.Require("BOTH")
+	c.RequireNE("BROKEN")
+	require.Len(t, c.Errors(), 2)
+}
@@ -368,6 +368,14 @@ func Test_GetEnvCsv(t *testing.T) {
 			"trims empty values", ",a,,b, ,	,c,,", []string{},
 			[]string{"a", "b", "c"},
 		},
+		{
+			"splits unterminated quote as-is", `"abc`, []string{},
+			[]string{`"abc`},
+		},
+		{
+			"splits malformed quotes as-is", `"a"b, c`, []string{},
+			[]string{`"a"b`, "c"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(
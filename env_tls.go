@@ -0,0 +1,384 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Names of variables read by `Env.GetTlsConfig()`. PEM values may also be read
+// from files named by the variables suffixed with `_FILE`, such as
+// `TLS_CERT_FILE`.
+const (
+	TlsCertName       = "TLS_CERT"
+	TlsKeyName        = "TLS_KEY"
+	TlsCaName         = "TLS_CA"
+	TlsMinVersionName = "TLS_MIN_VERSION"
+	TlsCiphersName    = "TLS_CIPHERS"
+	TlsClientAuthName = "TLS_CLIENT_AUTH"
+)
+
+var (
+	ErrTlsUnknownVersion    = errors.New("unknown TLS version")
+	ErrTlsUnknownCipher     = errors.New("unknown cipher suite")
+	ErrTls13Cipher          = errors.New("TLS 1.3 cipher suites are not configurable")
+	ErrTlsInsecureCipher    = errors.New("insecure cipher suite")
+	ErrTlsUnknownClientAuth = errors.New("unknown client auth mode")
+	ErrTlsKeyPair           = errors.New("certificate and key must be set together")
+	ErrTlsNoCertificate     = errors.New("no certificate found")
+	ErrTlsNoClientCa        = errors.New("verifying client certificates requires a CA")
+)
+
+var tlsEnvVars = []EnvVar{
+	{
+		Name: TlsCertName, Type: "PEM",
+		Description: "TLS certificate chain, or use " + TlsCertName +
+			EnvFileSuffix,
+	},
+	{
+		Name: TlsCertName + EnvFileSuffix, Type: "string",
+		Description: "Path of the TLS certificate chain, reloaded on change",
+	},
+	{
+		Name: TlsKeyName, Type: "PEM", Sensitive: true,
+		Description: "TLS private key, or use " + TlsKeyName + EnvFileSuffix,
+	},
+	{
+		Name: TlsKeyName + EnvFileSuffix, Type: "string",
+		Description: "Path of the TLS private key, reloaded on change",
+	},
+	{
+		Name: TlsCaName, Type: "PEM",
+		Description: "CA certificates verifying peers, or use " + TlsCaName +
+			EnvFileSuffix,
+	},
+	{
+		Name: TlsCaName + EnvFileSuffix, Type: "string",
+		Description: "Path of CA certificates verifying peers",
+	},
+	{
+		Name: TlsMinVersionName, Type: "string", Default: "1.2",
+		Description: "Minimum TLS version, such as 1.2 or 1.3",
+	},
+	{
+		Name: TlsCiphersName, Type: "[]string",
+		Description: "Allowed cipher suites of TLS 1.2 and below, such as " +
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	},
+	{
+		Name: TlsClientAuthName, Type: "string", Default: "none",
+		Description: "Client certificate policy: none, request, require, " +
+			"verify-if-given or require-and-verify",
+	},
+}
+
+func init() {
+	RegisterEnvParser(ParseTlsClientAuth)
+}
+
+// GetTlsConfig returns the TLS configuration described by variables of
+// `DefaultEnv`, see `Env.GetTlsConfig()`.
+func GetTlsConfig() (*tls.Config, error) {
+	return DefaultEnv.GetTlsConfig()
+}
+
+// GetTlsConfig returns the TLS configuration described by the following
+// variables, all variables are declared in the `Env`:
+//
+//   - `TLS_CERT` and `TLS_KEY` the PEM encoded certificate chain and private
+//     key, they must be set together;
+//   - `TLS_CA` the PEM encoded CA certificates verifying servers, and clients
+//     if client certificates are verified;
+//   - `TLS_MIN_VERSION` the minimum version, see `ParseTlsVersion()`, TLS 1.2
+//     by default;
+//   - `TLS_CIPHERS` comma separated names of allowed cipher suites, see
+//     `ParseTlsCipherSuite()`;
+//   - `TLS_CLIENT_AUTH` the client certificate policy, see
+//     `ParseTlsClientAuth()`.
+//
+// PEM values may be set inline, escaped line breaks `\n` being accepted, or
+// read from files named by `TLS_CERT_FILE`, `TLS_KEY_FILE` and `TLS_CA_FILE`.
+// If both the certificate and key are read from files, they are reloaded
+// whenever either file changes, so renewed certificates are picked up without
+// restart. The previous certificate is kept if the new one is invalid.
+//
+// It returns `nil` if none of the variables is set.
+func (e *Env) GetTlsConfig() (*tls.Config, error) {
+	e.Declare(tlsEnvVars...)
+	set := false
+	for _, v := range tlsEnvVars {
+		val, err := e.value(v.Name)
+		if err != nil {
+			return nil, err
+		}
+		set = set || "" != val
+	}
+	if !set {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	ver, ok, err := parseEnvScalar(
+		e, TlsMinVersionName, "uint16", ParseTlsVersion,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cfg.MinVersion = ver
+	}
+	cfg.CipherSuites, err = parseEnvCsv(
+		e, TlsCiphersName, "[]uint16", ParseTlsCipherSuite,
+	)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ClientAuth, err = GetEnvAsFrom(e, TlsClientAuthName, tls.NoClientCert)
+	if err != nil {
+		return nil, err
+	}
+	if err = e.setTlsCertificate(cfg); err != nil {
+		return nil, err
+	}
+	if err = e.setTlsCa(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (e *Env) setTlsCertificate(cfg *tls.Config) error {
+	cert, certFile, err := e.tlsPem(TlsCertName)
+	if err != nil {
+		return err
+	}
+	key, keyFile, err := e.tlsPem(TlsKeyName)
+	if err != nil {
+		return err
+	}
+	if (nil == cert) != (nil == key) {
+		return e.envError(TlsCertName, "", "PEM", ErrTlsKeyPair)
+	}
+	if nil == cert {
+		return nil
+	}
+	if "" == certFile || "" == keyFile {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return e.envError(TlsCertName, "", "PEM", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+		return nil
+	}
+	r := &tlsCertReloader{certFile: certFile, keyFile: keyFile}
+	if _, err = r.certificate(); err != nil {
+		return e.envError(TlsCertName+EnvFileSuffix, certFile, "PEM", err)
+	}
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return r.certificate()
+	}
+	cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (
+		*tls.Certificate, error,
+	) {
+		return r.certificate()
+	}
+	return nil
+}
+
+func (e *Env) setTlsCa(cfg *tls.Config) error {
+	ca, file, err := e.tlsPem(TlsCaName)
+	if err != nil {
+		return err
+	}
+	if nil == ca {
+		if cfg.ClientAuth >= tls.VerifyClientCertIfGiven {
+			return e.envError(
+				TlsClientAuthName, cfg.ClientAuth.String(), "tls.ClientAuthType",
+				ErrTlsNoClientCa,
+			)
+		}
+		return nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		if "" != file {
+			return e.envError(
+				TlsCaName+EnvFileSuffix, file, "PEM", ErrTlsNoCertificate,
+			)
+		}
+		return e.envError(TlsCaName, "", "PEM", ErrTlsNoCertificate)
+	}
+	cfg.RootCAs = pool
+	cfg.ClientCAs = pool
+	return nil
+}
+
+// tlsPem returns the PEM value of the variable named by the key, or the
+// content of the file named by `<KEY>_FILE` along with the file name. It
+// returns `nil` if neither is set.
+func (e *Env) tlsPem(key string) ([]byte, string, error) {
+	file, err := e.value(key + EnvFileSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+	if "" == file {
+		val, err := e.value(key)
+		if err != nil || "" == val {
+			return nil, "", err
+		}
+		if !strings.Contains(val, "\n") {
+			val = strings.ReplaceAll(val, `\n`, "\n")
+		}
+		return []byte(val), "", nil
+	}
+	if val, _ := e.Source.LookupEnv(e.Prefix + key); "" != val {
+		return nil, "", e.envError(key, "", "PEM", ErrEnvFileConflict)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", e.envError(key+EnvFileSuffix, file, "", err)
+	}
+	return content, file, nil
+}
+
+// tlsReloadInterval is the minimum time between checks of certificate files.
+var tlsReloadInterval = time.Second
+
+// tlsCertReloader loads the key pair from files, reloading it whenever the
+// modification time of either file changes. Files are checked at most once
+// every `tlsReloadInterval`, so handshakes mostly read the current pair
+// without locking.
+type tlsCertReloader struct {
+	certFile, keyFile string
+
+	cert atomic.Pointer[tls.Certificate]
+	// Unix time of the last check, in nanoseconds
+	checked atomic.Int64
+
+	// serializes checks, and guards the modification times
+	mu              sync.Mutex
+	certMod, keyMod time.Time
+}
+
+// certificate returns the current key pair. If the files have changed but
+// cannot be loaded, the previous pair is returned.
+func (r *tlsCertReloader) certificate() (*tls.Certificate, error) {
+	if cert := r.cert.Load(); nil != cert && r.fresh() {
+		return cert, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cert := r.cert.Load()
+	if nil != cert && r.fresh() {
+		return cert, nil
+	}
+	r.checked.Store(time.Now().UnixNano())
+	certMod, certErr := fileModTime(r.certFile)
+	keyMod, keyErr := fileModTime(r.keyFile)
+	if nil != cert && (nil != certErr || nil != keyErr ||
+		(certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod))) {
+		return cert, nil
+	}
+	pair, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if nil != cert {
+			return cert, nil
+		}
+		return nil, err
+	}
+	r.cert.Store(&pair)
+	r.certMod, r.keyMod = certMod, keyMod
+	return &pair, nil
+}
+
+// fresh reports whether files have been checked within `tlsReloadInterval`.
+func (r *tlsCertReloader) fresh() bool {
+	return time.Now().UnixNano()-r.checked.Load() < int64(tlsReloadInterval)
+}
+
+func fileModTime(file string) (time.Time, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// ParseTlsVersion parses TLS versions such as `1.2`, `TLS1.2`, `TLSv1.3` or
+// `tls13`, case-insensitively. SSL versions are not supported.
+func ParseTlsVersion(s string) (uint16, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "TLS"), "V")
+	v = strings.NewReplacer(".", "", "_", "", " ", "").Replace(v)
+	switch v {
+	case "10":
+		return tls.VersionTLS10, nil
+	case "11":
+		return tls.VersionTLS11, nil
+	case "12":
+		return tls.VersionTLS12, nil
+	case "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrTlsUnknownVersion, s)
+}
+
+// ParseTlsCipherSuite parses the case-insensitive name of a cipher suite, such
+// as `TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`, see `tls.CipherSuiteName()`.
+// Insecure cipher suites, see `tls.InsecureCipherSuites()`, are rejected with
+// `ErrTlsInsecureCipher`, and TLS 1.3 cipher suites, such as
+// `TLS_AES_128_GCM_SHA256`, which are ignored by `tls.Config`, with
+// `ErrTls13Cipher`.
+func ParseTlsCipherSuite(s string) (uint16, error) {
+	for _, cs := range tls.CipherSuites() {
+		if !strings.EqualFold(s, cs.Name) {
+			continue
+		}
+		if !slices.ContainsFunc(
+			cs.SupportedVersions, func(v uint16) bool {
+				return v < tls.VersionTLS13
+			},
+		) {
+			return 0, fmt.Errorf("%w: %s", ErrTls13Cipher, s)
+		}
+		return cs.ID, nil
+	}
+	for _, cs := range tls.InsecureCipherSuites() {
+		if strings.EqualFold(s, cs.Name) {
+			return 0, fmt.Errorf("%w: %s", ErrTlsInsecureCipher, s)
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrTlsUnknownCipher, s)
+}
+
+// ParseTlsClientAuth parses the client certificate policy. The following
+// values are supported case-insensitively, as well as names of
+// `tls.ClientAuthType` constants such as `RequireAndVerifyClientCert`:
+//
+//   - `none` no client certificate is requested;
+//   - `request` a certificate is requested but not required;
+//   - `require` a certificate is required but not verified;
+//   - `verify-if-given` a certificate is verified if provided;
+//   - `require-and-verify` a valid certificate is required.
+func ParseTlsClientAuth(s string) (tls.ClientAuthType, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	v = strings.NewReplacer("-", "", "_", "").Replace(v)
+	switch v {
+	case "none", "noclientcert":
+		return tls.NoClientCert, nil
+	case "request", "requestclientcert":
+		return tls.RequestClientCert, nil
+	case "require", "requireanyclientcert":
+		return tls.RequireAnyClientCert, nil
+	case "verifyifgiven", "verifyclientcertifgiven":
+		return tls.VerifyClientCertIfGiven, nil
+	case "requireandverify", "requireandverifyclientcert":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrTlsUnknownClientAuth, s)
+}
@@ -0,0 +1,390 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTlsCert struct {
+	cert, key []byte
+	x509      *x509.Certificate
+	priv      *ecdsa.PrivateKey
+}
+
+// newTestTlsCert returns a certificate for localhost signed by the parent, or
+// a self-signed CA certificate if parent is nil.
+func newTestTlsCert(tb testing.TB, parent *testTlsCert) *testTlsCert {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(tb, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.Nil(tb, err)
+	tpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth,
+		},
+	}
+	signer, signerKey := tpl, priv
+	if nil == parent {
+		tpl.Subject.CommonName = "test CA"
+		tpl.IsCA = true
+		tpl.BasicConstraintsValid = true
+		tpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.x509, parent.priv
+	}
+	der, err := x509.CreateCertificate(
+		rand.Reader, tpl, signer, &priv.PublicKey, signerKey,
+	)
+	require.Nil(tb, err)
+	crt, err := x509.ParseCertificate(der)
+	require.Nil(tb, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.Nil(tb, err)
+	return &testTlsCert{
+		cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key: pem.EncodeToMemory(
+			&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer},
+		),
+		x509: crt,
+		priv: priv,
+	}
+}
+
+func Test_Env_GetTlsConfig_returns_nil_if_not_set(t *testing.T) {
+	env := NewEnv(MapEnv{"OTHER_TLS_CERT": "x"})
+	cfg, err := env.GetTlsConfig()
+	require.Nil(t, err)
+	require.Nil(t, cfg)
+	v, ok := env.Declared.Get(TlsKeyName)
+	require.True(t, ok)
+	require.True(t, v.Sensitive)
+}
+
+func Test_Env_GetTlsConfig_inline(t *testing.T) {
+	ca := newTestTlsCert(t, nil)
+	leaf := newTestTlsCert(t, ca)
+	env := NewEnv(
+		MapEnv{
+			"API_TLS_CERT": string(leaf.cert),
+			"API_TLS_KEY": strings.ReplaceAll(
+				string(leaf.key), "\n", `\n`,
+			),
+			"API_TLS_CA":          string(ca.cert),
+			"API_TLS_MIN_VERSION": "TLSv1.3",
+			"API_TLS_CIPHERS": "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256," +
+				"tls_ecdhe_rsa_with_aes_256_gcm_sha384",
+			"API_TLS_CLIENT_AUTH": "require-and-verify",
+		},
+	)
+	cfg, err := env.Scope("API_").GetTlsConfig()
+	require.Nil(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	require.Equal(
+		t, []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		}, cfg.CipherSuites,
+	)
+	require.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	require.Len(t, cfg.Certificates, 1)
+	require.Nil(t, cfg.GetCertificate)
+	require.NotNil(t, cfg.RootCAs)
+	require.Same(t, cfg.RootCAs, cfg.ClientCAs)
+	_, ok := env.Declared.Get("API_" + TlsCaName + EnvFileSuffix)
+	require.True(t, ok)
+}
+
+func Test_Env_GetTlsConfig_defaults(t *testing.T) {
+	ca := newTestTlsCert(t, nil)
+	cfg, err := NewEnv(MapEnv{"TLS_CA": string(ca.cert)}).GetTlsConfig()
+	require.Nil(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	require.Nil(t, cfg.CipherSuites)
+	require.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+	require.Empty(t, cfg.Certificates)
+	require.NotNil(t, cfg.RootCAs)
+}
+
+func Test_Env_GetTlsConfig_reloads_files(t *testing.T) {
+	tmp := tlsReloadInterval
+	defer func() { tlsReloadInterval = tmp }()
+	tlsReloadInterval = 0
+	dir := t.TempDir()
+	ca := newTestTlsCert(t, nil)
+	leaf := newTestTlsCert(t, ca)
+	certFile := writeTestFile(t, dir, "cert.pem", leaf.cert)
+	keyFile := writeTestFile(t, dir, "key.pem", leaf.key)
+	env := NewEnv(
+		MapEnv{
+			"TLS_CERT_FILE": certFile,
+			"TLS_KEY_FILE":  keyFile,
+			"TLS_CA_FILE":   writeTestFile(t, dir, "ca.pem", ca.cert),
+		},
+	)
+	cfg, err := env.GetTlsConfig()
+	require.Nil(t, err)
+	require.Empty(t, cfg.Certificates)
+	cert, err := cfg.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, leaf.x509.Raw, cert.Certificate[0])
+	cert, err = cfg.GetClientCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, leaf.x509.Raw, cert.Certificate[0])
+
+	renewed := newTestTlsCert(t, ca)
+	later := time.Now().Add(time.Minute)
+	writeTestFile(t, dir, "cert.pem", renewed.cert)
+	writeTestFile(t, dir, "key.pem", renewed.key)
+	require.Nil(t, os.Chtimes(certFile, later, later))
+	require.Nil(t, os.Chtimes(keyFile, later, later))
+	cert, err = cfg.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, renewed.x509.Raw, cert.Certificate[0])
+
+	// keeps the previous certificate if the new one is invalid
+	writeTestFile(t, dir, "cert.pem", []byte("invalid"))
+	later = later.Add(time.Minute)
+	require.Nil(t, os.Chtimes(certFile, later, later))
+	cert, err = cfg.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, renewed.x509.Raw, cert.Certificate[0])
+	require.Nil(t, os.Remove(keyFile))
+	cert, err = cfg.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, renewed.x509.Raw, cert.Certificate[0])
+}
+
+func Test_Env_GetTlsConfig_checks_files_once_per_interval(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestTlsCert(t, nil)
+	leaf := newTestTlsCert(t, ca)
+	certFile := writeTestFile(t, dir, "cert.pem", leaf.cert)
+	keyFile := writeTestFile(t, dir, "key.pem", leaf.key)
+	env := NewEnv(MapEnv{"TLS_CERT_FILE": certFile, "TLS_KEY_FILE": keyFile})
+	cfg, err := env.GetTlsConfig()
+	require.Nil(t, err)
+
+	renewed := newTestTlsCert(t, ca)
+	later := time.Now().Add(time.Minute)
+	writeTestFile(t, dir, "cert.pem", renewed.cert)
+	writeTestFile(t, dir, "key.pem", renewed.key)
+	require.Nil(t, os.Chtimes(certFile, later, later))
+	require.Nil(t, os.Chtimes(keyFile, later, later))
+	cert, err := cfg.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, leaf.x509.Raw, cert.Certificate[0])
+}
+
+func Test_Env_GetTlsConfig_handshake(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestTlsCert(t, nil)
+	server := newTestTlsCert(t, ca)
+	client := newTestTlsCert(t, ca)
+	caFile := writeTestFile(t, dir, "ca.pem", ca.cert)
+	serverCfg, err := NewEnv(
+		MapEnv{
+			"TLS_CERT_FILE":   writeTestFile(t, dir, "cert.pem", server.cert),
+			"TLS_KEY_FILE":    writeTestFile(t, dir, "key.pem", server.key),
+			"TLS_CA_FILE":     caFile,
+			"TLS_CLIENT_AUTH": "RequireAndVerifyClientCert",
+		},
+	).GetTlsConfig()
+	require.Nil(t, err)
+	clientCfg, err := NewEnv(
+		MapEnv{
+			"TLS_CERT":    string(client.cert),
+			"TLS_KEY":     string(client.key),
+			"TLS_CA_FILE": caFile,
+		},
+	).GetTlsConfig()
+	require.Nil(t, err)
+	clientCfg.ServerName = "localhost"
+	sc, cc := net.Pipe()
+	defer sc.Close()
+	defer cc.Close()
+	errs := make(chan error, 1)
+	go func() { errs <- tls.Server(sc, serverCfg).Handshake() }()
+	conn := tls.Client(cc, clientCfg)
+	require.Nil(t, conn.Handshake())
+	require.Nil(t, <-errs)
+	require.Equal(
+		t, server.x509.Raw, conn.ConnectionState().PeerCertificates[0].Raw,
+	)
+}
+
+func Test_Env_GetTlsConfig_returns_error(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestTlsCert(t, nil)
+	leaf := newTestTlsCert(t, ca)
+	other := newTestTlsCert(t, ca)
+	certFile := writeTestFile(t, dir, "cert.pem", leaf.cert)
+	tests := []struct {
+		name string
+		env  MapEnv
+		key  string
+		err  error
+	}{
+		{
+			"key without certificate", MapEnv{"TLS_KEY": string(leaf.key)},
+			TlsCertName, ErrTlsKeyPair,
+		},
+		{
+			"mismatched key pair",
+			MapEnv{"TLS_CERT": string(leaf.cert), "TLS_KEY": string(other.key)},
+			TlsCertName, nil,
+		},
+		{
+			"invalid file pair",
+			MapEnv{
+				"TLS_CERT_FILE": certFile,
+				"TLS_KEY_FILE":  writeTestFile(t, dir, "other.pem", other.key),
+			},
+			TlsCertName + EnvFileSuffix, nil,
+		},
+		{
+			"missing file",
+			MapEnv{"TLS_CA_FILE": filepath.Join(dir, "missing")},
+			TlsCaName + EnvFileSuffix, os.ErrNotExist,
+		},
+		{
+			"conflict",
+			MapEnv{"TLS_CA": string(ca.cert), "TLS_CA_FILE": certFile},
+			TlsCaName, ErrEnvFileConflict,
+		},
+		{
+			"no CA certificate", MapEnv{"TLS_CA": "invalid"},
+			TlsCaName, ErrTlsNoCertificate,
+		},
+		{
+			"no CA certificate in file",
+			MapEnv{"TLS_CA_FILE": writeTestFile(t, dir, "ca.pem", "")},
+			TlsCaName + EnvFileSuffix, ErrTlsNoCertificate,
+		},
+		{
+			"unknown version", MapEnv{"TLS_MIN_VERSION": "3.0"},
+			TlsMinVersionName, ErrTlsUnknownVersion,
+		},
+		{
+			"unknown cipher",
+			MapEnv{
+				"TLS_CIPHERS": "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,RC4",
+			},
+			TlsCiphersName, ErrTlsUnknownCipher,
+		},
+		{
+			"TLS 1.3 cipher", MapEnv{"TLS_CIPHERS": "TLS_AES_128_GCM_SHA256"},
+			TlsCiphersName, ErrTls13Cipher,
+		},
+		{
+			"unknown client auth", MapEnv{"TLS_CLIENT_AUTH": "always"},
+			TlsClientAuthName, ErrTlsUnknownClientAuth,
+		},
+		{
+			"verify without CA", MapEnv{"TLS_CLIENT_AUTH": "verify-if-given"},
+			TlsClientAuthName, ErrTlsNoClientCa,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				cfg, err := NewEnv(tt.env).GetTlsConfig()
+				require.Nil(t, cfg)
+				if nil != tt.err {
+					require.ErrorIs(t, err, tt.err)
+				}
+				var ee *EnvError
+				require.ErrorAs(t, err, &ee)
+				require.Equal(t, tt.key, ee.Key)
+				require.NotContains(t, err.Error(), "PRIVATE KEY")
+			},
+		)
+	}
+}
+
+func Test_ParseTlsVersion(t *testing.T) {
+	tests := []struct {
+		s    string
+		want uint16
+	}{
+		{"1.0", tls.VersionTLS10},
+		{"TLS1.1", tls.VersionTLS11},
+		{" tlsv1.2 ", tls.VersionTLS12},
+		{"TLS13", tls.VersionTLS13},
+		{"TLS 1_3", tls.VersionTLS13},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.s, func(t *testing.T) {
+				v, err := ParseTlsVersion(tt.s)
+				require.Nil(t, err)
+				require.Equal(t, tt.want, v)
+			},
+		)
+	}
+	_, err := ParseTlsVersion("SSL3")
+	require.ErrorIs(t, err, ErrTlsUnknownVersion)
+}
+
+func Test_ParseTlsCipherSuite(t *testing.T) {
+	id, err := ParseTlsCipherSuite("tls_ecdhe_rsa_with_chacha20_poly1305_sha256")
+	require.Nil(t, err)
+	require.Equal(t, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256, id)
+	_, err = ParseTlsCipherSuite("TLS_CHACHA20_POLY1305_SHA256")
+	require.ErrorIs(t, err, ErrTls13Cipher)
+	_, err = ParseTlsCipherSuite("tls_rsa_with_rc4_128_sha")
+	require.ErrorIs(t, err, ErrTlsInsecureCipher)
+	require.EqualError(
+		t, err, "insecure cipher suite: tls_rsa_with_rc4_128_sha",
+	)
+	_, err = ParseTlsCipherSuite("TLS_UNKNOWN")
+	require.ErrorIs(t, err, ErrTlsUnknownCipher)
+}
+
+func Test_ParseTlsClientAuth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want tls.ClientAuthType
+	}{
+		{"none", tls.NoClientCert},
+		{"NoClientCert", tls.NoClientCert},
+		{"request", tls.RequestClientCert},
+		{"REQUIRE", tls.RequireAnyClientCert},
+		{"require_any_client_cert", tls.RequireAnyClientCert},
+		{"verify-if-given", tls.VerifyClientCertIfGiven},
+		{"require-and-verify", tls.RequireAndVerifyClientCert},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.s, func(t *testing.T) {
+				v, err := ParseTlsClientAuth(tt.s)
+				require.Nil(t, err)
+				require.Equal(t, tt.want, v)
+			},
+		)
+	}
+	v, err := ParseEnvValue[tls.ClientAuthType]("request")
+	require.Nil(t, err)
+	require.Equal(t, tls.RequestClientCert, v)
+	_, err = ParseTlsClientAuth("yes")
+	require.ErrorIs(t, err, ErrTlsUnknownClientAuth)
+}
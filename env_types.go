@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"time"
+)
+
+// GetEnvDuration returns the value of the environment variable named by
+// the key as a duration, such as `30s`. It is guaranteed to return the
+// default value if the environment variable is not found or empty.
+func GetEnvDuration(key string, defaultValue time.Duration) (
+	time.Duration, error,
+) {
+	return DefaultEnv.GetEnvDuration(key, defaultValue)
+}
+
+// GetEnvDurationCsv returns the value of the environment variable named by
+// the key as a slice of durations. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvDurationCsv(key string, defaultValue []time.Duration) (
+	[]time.Duration, error,
+) {
+	return DefaultEnv.GetEnvDurationCsv(key, defaultValue)
+}
+
+// GetEnvTime returns the value of the environment variable named by
+// the key as a time in RFC 3339 format. It is guaranteed to return the
+// default value if the environment variable is not found or empty.
+func GetEnvTime(key string, defaultValue time.Time) (time.Time, error) {
+	return DefaultEnv.GetEnvTime(key, defaultValue)
+}
+
+// GetEnvTimeCsv returns the value of the environment variable named by
+// the key as a slice of times in RFC 3339 format. It is guaranteed to return
+// the default value if the environment variable is not found.
+func GetEnvTimeCsv(key string, defaultValue []time.Time) (
+	[]time.Time, error,
+) {
+	return DefaultEnv.GetEnvTimeCsv(key, defaultValue)
+}
+
+// GetEnvUrl returns the value of the environment variable named by
+// the key as an URL. It is guaranteed to return the default value if
+// the environment variable is not found or empty.
+func GetEnvUrl(key string, defaultValue *url.URL) (*url.URL, error) {
+	return DefaultEnv.GetEnvUrl(key, defaultValue)
+}
+
+// GetEnvUrlCsv returns the value of the environment variable named by
+// the key as a slice of URLs. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvUrlCsv(key string, defaultValue []*url.URL) ([]*url.URL, error) {
+	return DefaultEnv.GetEnvUrlCsv(key, defaultValue)
+}
+
+// GetEnvByteSize returns the value of the environment variable named by
+// the key as a byte size, such as `64MiB`. It is guaranteed to return the
+// default value if the environment variable is not found or empty. See
+// `ParseByteSize()` for supported units.
+func GetEnvByteSize(key string, defaultValue ByteSize) (ByteSize, error) {
+	return DefaultEnv.GetEnvByteSize(key, defaultValue)
+}
+
+// GetEnvByteSizeCsv returns the value of the environment variable named by
+// the key as a slice of byte sizes. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvByteSizeCsv(key string, defaultValue []ByteSize) (
+	[]ByteSize, error,
+) {
+	return DefaultEnv.GetEnvByteSizeCsv(key, defaultValue)
+}
+
+// GetEnvIp returns the value of the environment variable named by
+// the key as an IP address. It is guaranteed to return the default value if
+// the environment variable is not found or empty.
+func GetEnvIp(key string, defaultValue net.IP) (net.IP, error) {
+	return DefaultEnv.GetEnvIp(key, defaultValue)
+}
+
+// GetEnvIpCsv returns the value of the environment variable named by
+// the key as a slice of IP addresses. It is guaranteed to return the default
+// value if the environment variable is not found.
+func GetEnvIpCsv(key string, defaultValue []net.IP) ([]net.IP, error) {
+	return DefaultEnv.GetEnvIpCsv(key, defaultValue)
+}
+
+// GetEnvPrefix returns the value of the environment variable named by
+// the key as an IP network prefix, such as `10.0.0.0/8`. It is guaranteed to
+// return the default value if the environment variable is not found or empty.
+func GetEnvPrefix(key string, defaultValue netip.Prefix) (
+	netip.Prefix, error,
+) {
+	return DefaultEnv.GetEnvPrefix(key, defaultValue)
+}
+
+// GetEnvPrefixCsv returns the value of the environment variable named by
+// the key as a slice of IP network prefixes. It is guaranteed to return the
+// default value if the environment variable is not found.
+func GetEnvPrefixCsv(key string, defaultValue []netip.Prefix) (
+	[]netip.Prefix, error,
+) {
+	return DefaultEnv.GetEnvPrefixCsv(key, defaultValue)
+}
+
+// GetEnvDuration returns the value of the variable named by the key as a
+// duration, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvDuration(key string, defaultValue time.Duration) (
+	time.Duration, error,
+) {
+	return GetEnvAsFrom(e, key, defaultValue)
+}
+
+// GetEnvDurationCsv returns the value of the variable named by the key as a
+// slice of durations, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvDurationCsv(key string, defaultValue []time.Duration) (
+	[]time.Duration, error,
+) {
+	return GetEnvSliceAsFrom(e, key, defaultValue)
+}
+
+// GetEnvTime returns the value of the variable named by the key as a time in
+// RFC 3339 format, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvTime(key string, defaultValue time.Time) (
+	time.Time, error,
+) {
+	return GetEnvAsFrom(e, key, defaultValue)
+}
+
+// GetEnvTimeCsv returns the value of the variable named by the key as a slice
+// of times in RFC 3339 format, or the default value if the variable is not
+// found or empty.
+func (e *Env) GetEnvTimeCsv(key string, defaultValue []time.Time) (
+	[]time.Time, error,
+) {
+	return GetEnvSliceAsFrom(e, key, defaultValue)
+}
+
+// GetEnvUrl returns the value of the variable named by the key as an URL, or
+// the default value if the variable is not found or empty.
+func (e *Env) GetEnvUrl(key string, defaultValue *url.URL) (*url.URL, error) {
+	return GetEnvAsFrom(e, key, defaultValue)
+}
+
+// GetEnvUrlCsv returns the value of the variable named by the key as a slice
+// of URLs, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvUrlCsv(key string, defaultValue []*url.URL) (
+	[]*url.URL, error,
+) {
+	return GetEnvSliceAsFrom(e, key, defaultValue)
+}
+
+// GetEnvByteSize returns the value of the variable named by the key as a byte
+// size, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvByteSize(key string, defaultValue ByteSize) (
+	ByteSize, error,
+) {
+	return GetEnvAsFrom(e, key, defaultValue)
+}
+
+// GetEnvByteSizeCsv returns the value of the variable named by the key as a
+// slice of byte sizes, or the default value if the variable is not found or
+// empty.
+func (e *Env) GetEnvByteSizeCsv(key string, defaultValue []ByteSize) (
+	[]ByteSize, error,
+) {
+	return GetEnvSliceAsFrom(e, key, defaultValue)
+}
+
+// GetEnvIp returns the value of the variable named by the key as an IP
+// address, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvIp(key string, defaultValue net.IP) (net.IP, error) {
+	return GetEnvAsFrom(e, key, defaultValue)
+}
+
+// GetEnvIpCsv returns the value of the variable named by the key as a slice
+// of IP addresses, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvIpCsv(key string, defaultValue []net.IP) (
+	[]net.IP, error,
+) {
+	return GetEnvSliceAsFrom(e, key, defaultValue)
+}
+
+// GetEnvPrefix returns the value of the variable named by the key as an IP
+// network prefix, or the default value if the variable is not found or empty.
+func (e *Env) GetEnvPrefix(key string, defaultValue netip.Prefix) (
+	netip.Prefix, error,
+) {
+	return GetEnvAsFrom(e, key, defaultValue)
+}
+
+// GetEnvPrefixCsv returns the value of the variable named by the key as a
+// slice of IP network prefixes, or the default value if the variable is not
+// found or empty.
+func (e *Env) GetEnvPrefixCsv(key string, defaultValue []netip.Prefix) (
+	[]netip.Prefix, error,
+) {
+	return GetEnvSliceAsFrom(e, key, defaultValue)
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetEnvDuration(t *testing.T) {
+	t.Setenv("TEST_ENV_DURATION", "30s")
+	t.Setenv("TEST_ENV_DURATION_EMPTY", "")
+	t.Setenv("TEST_ENV_DURATION_CSV", "1s, 1m")
+	got, err := GetEnvDuration("TEST_ENV_DURATION", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, 30*time.Second, got)
+	got, err = GetEnvDuration("TEST_ENV_DURATION_EMPTY", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, time.Second, got)
+	_, err = GetEnvDuration("TEST_ENV_DURATION_CSV", time.Second)
+	require.NotNil(t, err)
+	t.Setenv("TEST_ENV_DURATION_CSV", "1s,1m")
+	gots, err := GetEnvDurationCsv("TEST_ENV_DURATION_CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []time.Duration{time.Second, time.Minute}, gots)
+}
+
+func Test_GetEnvTime(t *testing.T) {
+	t.Setenv("TEST_ENV_TIME", "2024-01-02T03:04:05Z")
+	t.Setenv("TEST_ENV_TIME_INVALID", "2024-01-02")
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	got, err := GetEnvTime("TEST_ENV_TIME", time.Time{})
+	require.Nil(t, err)
+	require.True(t, want.Equal(got))
+	got, err = GetEnvTime("TEST_ENV_TIME_MISSING", want)
+	require.Nil(t, err)
+	require.Equal(t, want, got)
+	_, err = GetEnvTime("TEST_ENV_TIME_INVALID", want)
+	require.NotNil(t, err)
+	gots, err := GetEnvTimeCsv("TEST_ENV_TIME", nil)
+	require.Nil(t, err)
+	require.Len(t, gots, 1)
+	require.True(t, want.Equal(gots[0]))
+}
+
+func Test_GetEnvUrl(t *testing.T) {
+	t.Setenv("TEST_ENV_URL", "https://user@example.com:8443/path?q=1")
+	t.Setenv("TEST_ENV_URL_INVALID", "http://[::1")
+	def := &url.URL{Scheme: "http", Host: "localhost"}
+	got, err := GetEnvUrl("TEST_ENV_URL", def)
+	require.Nil(t, err)
+	require.Equal(t, "example.com:8443", got.Host)
+	require.Equal(t, "1", got.Query().Get("q"))
+	got, err = GetEnvUrl("TEST_ENV_URL_MISSING", def)
+	require.Nil(t, err)
+	require.Same(t, def, got)
+	_, err = GetEnvUrl("TEST_ENV_URL_INVALID", def)
+	require.NotNil(t, err)
+	gots, err := GetEnvUrlCsv("TEST_ENV_URL", nil)
+	require.Nil(t, err)
+	require.Equal(t, "/path", gots[0].Path)
+}
+
+func Test_GetEnvByteSize(t *testing.T) {
+	t.Setenv("TEST_ENV_BYTE_SIZE", "64MiB")
+	t.Setenv("TEST_ENV_BYTE_SIZE_CSV", "1kB,2KiB")
+	got, err := GetEnvByteSize("TEST_ENV_BYTE_SIZE", KiB)
+	require.Nil(t, err)
+	require.Equal(t, 64*MiB, got)
+	got, err = GetEnvByteSize("TEST_ENV_BYTE_SIZE_MISSING", KiB)
+	require.Nil(t, err)
+	require.Equal(t, KiB, got)
+	_, err = GetEnvByteSize("TEST_ENV_BYTE_SIZE_CSV", KiB)
+	require.ErrorIs(t, err, ErrInvalidByteSize)
+	gots, err := GetEnvByteSizeCsv("TEST_ENV_BYTE_SIZE_CSV", nil)
+	require.Nil(t, err)
+	require.Equal(t, []ByteSize{KB, 2 * KiB}, gots)
+}
+
+func Test_GetEnvIp(t *testing.T) {
+	t.Setenv("TEST_ENV_IP", "192.168.1.1")
+	t.Setenv("TEST_ENV_IP_CSV", "10.0.0.1,::1")
+	t.Setenv("TEST_ENV_IP_INVALID", "300.1.1.1")
+	got, err := GetEnvIp("TEST_ENV_IP", nil)
+	require.Nil(t, err)
+	require.True(t, net.IPv4(192, 168, 1, 1).Equal(got))
+	got, err = GetEnvIp("TEST_ENV_IP_MISSING", net.IPv6loopback)
+	require.Nil(t, err)
+	require.Equal(t, net.IPv6loopback, got)
+	_, err = GetEnvIp("TEST_ENV_IP_INVALID", nil)
+	require.NotNil(t, err)
+	gots, err := GetEnvIpCsv("TEST_ENV_IP_CSV", nil)
+	require.Nil(t, err)
+	require.Len(t, gots, 2)
+	require.True(t, net.IPv6loopback.Equal(gots[1]))
+}
+
+func Test_GetEnvPrefix(t *testing.T) {
+	t.Setenv("TEST_ENV_PREFIX", "10.0.0.0/8")
+	t.Setenv("TEST_ENV_PREFIX_CSV", "10.0.0.0/8,192.168.0.0/16")
+	t.Setenv("TEST_ENV_PREFIX_INVALID", "10.0.0.0")
+	got, err := GetEnvPrefix("TEST_ENV_PREFIX", netip.Prefix{})
+	require.Nil(t, err)
+	require.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), got)
+	_, err = GetEnvPrefix("TEST_ENV_PREFIX_INVALID", netip.Prefix{})
+	require.NotNil(t, err)
+	def := []netip.Prefix{netip.MustParsePrefix("::/0")}
+	gots, err := GetEnvPrefixCsv("TEST_ENV_PREFIX_MISSING", def)
+	require.Nil(t, err)
+	require.Equal(t, def, gots)
+	gots, err = GetEnvPrefixCsv("TEST_ENV_PREFIX_CSV", nil)
+	require.Nil(t, err)
+	require.Equal(
+		t, []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("192.168.0.0/16"),
+		}, gots,
+	)
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var ErrEnvUnknown = errors.New("unknown environment variable")
+
+// maxEnvSuggestions is the maximum number of suggestions of an unknown
+// variable.
+const maxEnvSuggestions = 3
+
+// EnvUsage records names of variables read from `Env`. It is safe for
+// concurrent use.
+type EnvUsage struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// UnknownEnv is a variable present in the source that nobody read.
+type UnknownEnv struct {
+	// Name of the variable
+	Key string
+	// Names of read variables similar to `Key`, the most similar first
+	Suggestions []string
+}
+
+// NewEnvUsage returns an empty `EnvUsage`.
+func NewEnvUsage() *EnvUsage {
+	return &EnvUsage{keys: map[string]struct{}{}}
+}
+
+// Keys returns the sorted names of all read variables.
+func (u *EnvUsage) Keys() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	keys := make([]string, 0, len(u.keys))
+	for key := range u.keys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Has reports whether the variable named by the key has been read.
+func (u *EnvUsage) Has(key string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	_, ok := u.keys[key]
+	return ok
+}
+
+func (u *EnvUsage) add(key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if nil == u.keys {
+		u.keys = map[string]struct{}{}
+	}
+	u.keys[key] = struct{}{}
+}
+
+// FindUnknownEnv returns variables of the process environment starting with
+// any of the prefixes that have not been read through `DefaultEnv`.
+func FindUnknownEnv(prefixes ...string) []UnknownEnv {
+	return DefaultEnv.FindUnknown(prefixes...)
+}
+
+// CheckUnknownEnv returns an `EnvErrors` listing variables of the process
+// environment starting with any of the prefixes that have not been read
+// through `DefaultEnv`, or `nil` if there is none.
+func CheckUnknownEnv(prefixes ...string) error {
+	return DefaultEnv.CheckUnknown(prefixes...)
+}
+
+// FindUnknown returns variables under the scope starting with any of the
+// prefixes that have not been read, along with suggestions of similar read
+// variables. All variables under the scope are considered if no prefix is
+// given. Keys are full names including `Prefix`. Nothing is reported if
+// `Usage` is `nil`.
+func (e *Env) FindUnknown(prefixes ...string) []UnknownEnv {
+	if nil == e.Usage {
+		return nil
+	}
+	if 0 == len(prefixes) {
+		prefixes = []string{""}
+	}
+	used := e.Usage.Keys()
+	var unknown []UnknownEnv
+	for _, key := range e.Source.Keys() {
+		if e.Usage.Has(key) {
+			continue
+		}
+		prefix, ok := matchPrefix(key, e.Prefix, prefixes)
+		if !ok {
+			continue
+		}
+		unknown = append(
+			unknown,
+			UnknownEnv{
+				Key: key, Suggestions: suggestEnvKeys(key, prefix, used),
+			},
+		)
+	}
+	return unknown
+}
+
+// CheckUnknown is the same as `FindUnknown()`, reporting unknown variables as
+// `EnvErrors`. It returns `nil` if there is none.
+func (e *Env) CheckUnknown(prefixes ...string) error {
+	c := e.NewChecker()
+	c.CheckUnknown(prefixes...)
+	return c.Err()
+}
+
+// CheckUnknown records variables found by `Env.FindUnknown()` as problems.
+func (c *EnvChecker) CheckUnknown(prefixes ...string) {
+	for _, u := range c.env.FindUnknown(prefixes...) {
+		val, _ := c.env.Source.LookupEnv(u.Key)
+		ee := newEnvError(u.Key, val, "", ErrEnvUnknown)
+		if len(u.Suggestions) > 0 {
+			ee.Hint = "did you mean " +
+				strings.Join(u.Suggestions, " or ") + "?"
+		}
+		c.errs = append(c.errs, ee)
+	}
+}
+
+// matchPrefix returns the longest of the prefixes, under the scope, that the
+// key starts with. The returned boolean is `false` if there is none.
+func matchPrefix(key, scope string, prefixes []string) (string, bool) {
+	match, found := "", false
+	for _, prefix := range prefixes {
+		p := scope + prefix
+		if strings.HasPrefix(key, p) && (!found || len(p) > len(match)) {
+			match, found = p, true
+		}
+	}
+	return match, found
+}
+
+// suggestEnvKeys returns the keys close enough to the given one to be typos
+// of each other, the closest first. Only keys with the same prefix are
+// considered, and distances are computed without the prefix, so short names
+// under a long prefix do not get unrelated suggestions.
+func suggestEnvKeys(key, prefix string, keys []string) []string {
+	name := strings.TrimPrefix(key, prefix)
+	limit := max(1, min(2, len(name)/4))
+	type candidate struct {
+		key  string
+		dist int
+	}
+	var candidates []candidate
+	for _, k := range keys {
+		n, ok := strings.CutPrefix(k, prefix)
+		if !ok {
+			continue
+		}
+		if d := EditDistance(name, n); d <= limit {
+			candidates = append(candidates, candidate{k, d})
+		}
+	}
+	slices.SortStableFunc(
+		candidates,
+		func(a, b candidate) int { return cmp.Compare(a.dist, b.dist) },
+	)
+	suggestions := make([]string, 0, maxEnvSuggestions)
+	for i := 0; i < len(candidates) && i < maxEnvSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].key)
+	}
+	if 0 == len(suggestions) {
+		return nil
+	}
+	return suggestions
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvUsage_records_lookups(t *testing.T) {
+	env := NewEnv(MapEnv{"A": "1"})
+	env.GetEnv("A", "", false)
+	_, _ = env.Scope("DB_").GetEnvInt64("PORT", 0)
+	require.Equal(t, []string{"A", "DB_PORT"}, env.Usage.Keys())
+	require.True(t, env.Usage.Has("DB_PORT"))
+	require.False(t, env.Usage.Has("PORT"))
+	env.FileSecrets = true
+	env.GetEnv("B", "", false)
+	require.True(t, env.Usage.Has("B_FILE"))
+}
+
+func Test_Env_FindUnknown(t *testing.T) {
+	env := NewEnv(
+		MapEnv{
+			"PASSWORD_HASH_TIMES":  "2",
+			"PASSWORD_HASH_THREDS": "8",
+			"PASSWORD_HASH_EXTRA":  "x",
+			"HOME":                 "/root",
+		},
+	)
+	for _, key := range []string{
+		PasswordHashTimesName, PasswordHashThreadsName,
+		PasswordHashMemoryName,
+	} {
+		_, err := env.GetEnvUint(key, 0, 32)
+		require.Nil(t, err)
+	}
+	require.Equal(
+		t, []UnknownEnv{
+			{Key: "PASSWORD_HASH_EXTRA"},
+			{
+				Key:         "PASSWORD_HASH_THREDS",
+				Suggestions: []string{PasswordHashThreadsName},
+			},
+		},
+		env.FindUnknown("PASSWORD_HASH_"),
+	)
+	require.Len(t, env.FindUnknown(), 3)
+	require.Nil(t, env.FindUnknown("NONE_"))
+}
+
+func Test_Env_FindUnknown_in_scope(t *testing.T) {
+	env := NewEnv(MapEnv{"APP_HOST": "h", "APP_HSOT": "x", "HOST": "y"})
+	app := env.Scope("APP_")
+	app.GetEnv("HOST", "", false)
+	require.Equal(
+		t, []UnknownEnv{{Key: "APP_HSOT", Suggestions: []string{"APP_HOST"}}},
+		app.FindUnknown(),
+	)
+}
+
+func Test_Env_FindUnknown_without_usage(t *testing.T) {
+	env := &Env{Source: MapEnv{"A": "1"}}
+	env.GetEnv("B", "", false)
+	require.Nil(t, env.FindUnknown())
+}
+
+func Test_Env_CheckUnknown(t *testing.T) {
+	env := NewEnv(MapEnv{"APP_THREDS": "8", "APP_OTHER": "1"})
+	_, _ = env.GetEnvInt64("APP_THREADS", 0)
+	err := env.CheckUnknown("APP_")
+	require.ErrorIs(t, err, ErrEnvUnknown)
+	var ee EnvErrors
+	require.ErrorAs(t, err, &ee)
+	require.Len(t, ee, 2)
+	require.Equal(t, "APP_OTHER", ee[0].Key)
+	require.Equal(t, "unknown environment variable: APP_OTHER", ee[0].Error())
+	require.Equal(
+		t,
+		"unknown environment variable: APP_THREDS (did you mean APP_THREADS?)",
+		ee[1].Error(),
+	)
+	require.Equal(t, "8", ee[1].Value)
+	_, _ = env.GetEnvInt64("APP_THREDS", 0)
+	_, _ = env.GetEnvInt64("APP_OTHER", 0)
+	require.Nil(t, env.CheckUnknown("APP_"))
+}
+
+func Test_CheckUnknownEnv(t *testing.T) {
+	t.Setenv("TEST_UNKNOWN_NAME", "a")
+	t.Setenv("TEST_UNKNOWN_NAEM", "b")
+	GetEnv("TEST_UNKNOWN_NAME", "", false)
+	require.Equal(
+		t, []UnknownEnv{
+			{
+				Key:         "TEST_UNKNOWN_NAEM",
+				Suggestions: []string{"TEST_UNKNOWN_NAME"},
+			},
+		},
+		FindUnknownEnv("TEST_UNKNOWN_"),
+	)
+	require.ErrorIs(t, CheckUnknownEnv("TEST_UNKNOWN_"), ErrEnvUnknown)
+}
+
+func Test_suggestEnvKeys(t *testing.T) {
+	keys := []string{"PORT", "HOST", "HOSTS", "POST", "PASSWORD"}
+	require.Equal(
+		t, []string{"HOST", "HOSTS"}, suggestEnvKeys("HOTS", "", keys),
+	)
+	require.Equal(
+		t, []string{"PORT"}, suggestEnvKeys("PORTT", "", keys),
+	)
+	require.Equal(
+		t, []string{"PASSWORD"}, suggestEnvKeys("PASWORD", "", keys),
+	)
+	require.Nil(t, suggestEnvKeys("USER", "", keys))
+}
+
+func Test_suggestEnvKeys_ignores_prefix(t *testing.T) {
+	keys := []string{"APP_PORT", "APP_HOST", "PORT", "OTHER_HOTS"}
+	require.Nil(t, suggestEnvKeys("APP_HOTE", "APP_", []string{"APP_PORT"}))
+	require.Equal(
+		t, []string{"APP_HOST"}, suggestEnvKeys("APP_HOTS", "APP_", keys),
+	)
+	require.Equal(
+		t, []string{"APP_PORT"}, suggestEnvKeys("APP_PROT", "APP_", keys),
+	)
+	env := NewEnv(MapEnv{"APP_HOST": "h", "APP_PORT": "1"})
+	env.GetEnv("APP_PORT", "", false)
+	require.Equal(
+		t, []UnknownEnv{{Key: "APP_HOST"}}, env.FindUnknown("APP_"),
+	)
+}
+
+func Test_CheckUnknownEnv_password_hash_typo(t *testing.T) {
+	t.Setenv("PASSWORD_HASH_THREDS", "8")
+	params, err := DefaultPasswordHashParams()
+	require.Nil(t, err)
+	require.Equal(t, uint8(4), params.Threads)
+	require.ErrorContains(
+		t, CheckUnknownEnv("PASSWORD_HASH_"),
+		"PASSWORD_HASH_THREDS (did you mean PASSWORD_HASH_THREADS?)",
+	)
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var _ EnvSource = (*EnvWatcher)(nil)
+
+// EnvChange describes a variable changed by a reload.
+type EnvChange struct {
+	Key string
+	// Value before the change, empty if the variable was not set
+	Old string
+	// Value after the change, empty if the variable has been removed
+	New string
+	// Whether the variable was set before the change
+	Existed bool
+	// Whether the variable is set after the change
+	Exists bool
+}
+
+// DiffEnv returns variables added, removed or modified from `before` to
+// `after`, sorted by name.
+func DiffEnv(before, after EnvSource) []EnvChange {
+	keys := append(before.Keys(), after.Keys()...)
+	slices.Sort(keys)
+	var changes []EnvChange
+	for _, key := range slices.Compact(keys) {
+		old, existed := before.LookupEnv(key)
+		val, exists := after.LookupEnv(key)
+		if existed != exists || old != val {
+			changes = append(changes, EnvChange{key, old, val, existed, exists})
+		}
+	}
+	return changes
+}
+
+// EnvWatcher holds variables of a .env or JSON config file, reloaded on an
+// interval or on signals by `Watch()`, or explicitly by `Reload()`. Files
+// ending with `.json` are read by `ReadJsonConfig()`, others by
+// `ReadDotEnv()`.
+//
+// The watcher is an `EnvSource` of the variables last loaded, so
+// `NewEnv(ChainEnv{OsEnv{}, w})` always reads current values. Subscribers are
+// notified of changes by `Subscribe()` and `SubscribeEnv()`. It is safe for
+// concurrent use, but fields must not be changed once `Watch()` is called.
+type EnvWatcher struct {
+	// File to read variables from
+	File string
+	// Time between reloads, the file is not polled if it is zero
+	Interval time.Duration
+	// Signals triggering a reload, `syscall.SIGHUP` by default
+	Signals []os.Signal
+	// Checks the new variables before they are applied, rejecting the reload
+	// if it returns an error. It may be `nil`.
+	Validate func(*Env) error
+	// Receives errors of reloads triggered by `Watch()`. It may be `nil`.
+	OnError func(error)
+	// Lenient parsing of values passed to `SubscribeEnv()` callbacks
+	Parse EnvParseMode
+
+	// serializes reloads, so changes are diffed against applied variables
+	reload sync.Mutex
+	mu     sync.RWMutex
+	vars   MapEnv
+	subs   []envSubscriber
+}
+
+// envSubscriber prepares the notification of a subscriber. It returns an
+// error if the new value is invalid, or `nil` if there is nothing to notify.
+type envSubscriber func(changes []EnvChange) (func(), error)
+
+// NewEnvWatcher returns an `EnvWatcher` of the file, reloaded on `SIGHUP`.
+// Variables are loaded immediately, returning the error if the file cannot be
+// read.
+func NewEnvWatcher(file string) (*EnvWatcher, error) {
+	w := &EnvWatcher{File: file, Signals: []os.Signal{syscall.SIGHUP}}
+	vars, err := w.read()
+	if err != nil {
+		return nil, err
+	}
+	w.vars = vars
+	return w, nil
+}
+
+func (w *EnvWatcher) LookupEnv(key string) (string, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.vars.LookupEnv(key)
+}
+
+func (w *EnvWatcher) Keys() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.vars.Keys()
+}
+
+// Subscribe registers the callback receiving all changes of each reload.
+func (w *EnvWatcher) Subscribe(fn func(changes []EnvChange)) {
+	w.subscribe(
+		func(changes []EnvChange) (func(), error) {
+			return func() { fn(changes) }, nil
+		},
+	)
+}
+
+// SubscribeEnv registers the callback receiving old and new values of the
+// variable named by the key, parsed as `T`, whenever it changes. The default
+// value is used if the variable is not set or empty. A reload is rejected if
+// the new value cannot be parsed, so callbacks only receive valid values. See
+// `ParseEnvValue()` for supported types.
+func SubscribeEnv[T any](
+	w *EnvWatcher, key string, defaultValue T, fn func(old, new T),
+) {
+	parse := func(val string) (T, error) {
+		if "" == val {
+			return defaultValue, nil
+		}
+		return parseEnvValueAs[T](val, w.Parse)
+	}
+	w.subscribe(
+		func(changes []EnvChange) (func(), error) {
+			i := slices.IndexFunc(
+				changes, func(c EnvChange) bool { return key == c.Key },
+			)
+			if i < 0 {
+				return nil, nil
+			}
+			c := changes[i]
+			val, err := parse(c.New)
+			if err != nil {
+				return nil, newEnvError(
+					key, c.New, reflect.TypeFor[T]().String(), err,
+				)
+			}
+			// values loaded before subscribing have not been validated
+			old, err := parse(c.Old)
+			if err != nil {
+				old = defaultValue
+			}
+			return func() { fn(old, val) }, nil
+		},
+	)
+}
+
+func (w *EnvWatcher) subscribe(sub envSubscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs = append(w.subs, sub)
+}
+
+// Reload reads the file, validates the new variables and applies them, then
+// notifies subscribers of the changes, in the order they subscribed. Nothing
+// is changed if the file cannot be read, or the new variables are rejected by
+// `Validate` or `SubscribeEnv()` parsers. It returns the changes applied.
+func (w *EnvWatcher) Reload() ([]EnvChange, error) {
+	w.reload.Lock()
+	defer w.reload.Unlock()
+	vars, err := w.read()
+	if err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	changes := DiffEnv(w.vars, vars)
+	subs := slices.Clone(w.subs)
+	w.mu.Unlock()
+	if 0 == len(changes) {
+		return nil, nil
+	}
+	if nil != w.Validate {
+		if err = w.Validate(NewEnv(vars)); err != nil {
+			return nil, err
+		}
+	}
+	var notify []func()
+	for _, sub := range subs {
+		fn, err := sub(changes)
+		if err != nil {
+			return nil, err
+		}
+		if nil != fn {
+			notify = append(notify, fn)
+		}
+	}
+	w.mu.Lock()
+	w.vars = vars
+	w.mu.Unlock()
+	for _, fn := range notify {
+		fn()
+	}
+	return changes, nil
+}
+
+// Watch reloads the file every `Interval`, and whenever one of `Signals` is
+// received, until the context is done. Errors are passed to `OnError`, the
+// current variables being kept, so a file caught in the middle of being
+// written is picked up by the next reload. It blocks, and is usually run in
+// its own goroutine.
+func (w *EnvWatcher) Watch(ctx context.Context) {
+	var tick <-chan time.Time
+	if w.Interval > 0 {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	sig := make(chan os.Signal, 1)
+	if len(w.Signals) > 0 {
+		signal.Notify(sig, w.Signals...)
+		defer signal.Stop(sig)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-sig:
+		}
+		if _, err := w.Reload(); err != nil && nil != w.OnError {
+			w.OnError(err)
+		}
+	}
+}
+
+func (w *EnvWatcher) read() (MapEnv, error) {
+	if strings.EqualFold(".json", filepath.Ext(w.File)) {
+		return ReadJsonConfig(w.File)
+	}
+	return ReadDotEnv(w.File)
+}
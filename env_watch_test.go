@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEnvWatcher(
+	tb testing.TB, dir, name, content string,
+) *EnvWatcher {
+	w, err := NewEnvWatcher(writeTestFile(tb, dir, name, content))
+	require.Nil(tb, err)
+	return w
+}
+
+func Test_DiffEnv(t *testing.T) {
+	changes := DiffEnv(
+		MapEnv{"A": "1", "B": "2", "C": "", "D": "4"},
+		MapEnv{"A": "1", "B": "3", "C": "", "E": "", "D": "4"},
+	)
+	require.Equal(
+		t, []EnvChange{
+			{"B", "2", "3", true, true},
+			{"E", "", "", false, true},
+		}, changes,
+	)
+	require.Equal(
+		t, []EnvChange{{"A", "1", "", true, false}},
+		DiffEnv(MapEnv{"A": "1"}, MapEnv{}),
+	)
+	require.Nil(t, DiffEnv(MapEnv{"A": "1"}, MapEnv{"A": "1"}))
+}
+
+func Test_NewEnvWatcher(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestEnvWatcher(t, dir, ".env", "LEVEL=info\nRATE=10")
+	require.Equal(t, []string{"LEVEL", "RATE"}, w.Keys())
+	val, found := w.LookupEnv("LEVEL")
+	require.True(t, found)
+	require.Equal(t, "info", val)
+	require.Equal(t, []os.Signal{syscall.SIGHUP}, w.Signals)
+	w = newTestEnvWatcher(t, dir, "config.JSON", `{"rate": {"limit": 10}}`)
+	require.Equal(t, "10", NewEnv(w).MustGetEnv("RATE_LIMIT"))
+	_, err := NewEnvWatcher(filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_EnvWatcher_Reload(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestEnvWatcher(t, dir, ".env", "LEVEL=info\nRATE=10\nDEBUG=false")
+	env := NewEnv(w)
+	var all [][]EnvChange
+	w.Subscribe(func(changes []EnvChange) { all = append(all, changes) })
+	var rates [][2]int
+	SubscribeEnv(w, "RATE", 5, func(old, new int) {
+		rates = append(rates, [2]int{old, new})
+	})
+	var debug []bool
+	SubscribeEnv(w, "DEBUG", false, func(_, new bool) {
+		debug = append(debug, new)
+	})
+
+	writeTestFile(t, dir, ".env", "LEVEL=warn\nRATE=20\nDEBUG=false")
+	changes, err := w.Reload()
+	require.Nil(t, err)
+	require.Equal(
+		t, []EnvChange{
+			{"LEVEL", "info", "warn", true, true},
+			{"RATE", "10", "20", true, true},
+		}, changes,
+	)
+	require.Equal(t, [][]EnvChange{changes}, all)
+	require.Equal(t, [][2]int{{10, 20}}, rates)
+	require.Empty(t, debug)
+	require.Equal(t, "warn", env.MustGetEnv("LEVEL"))
+
+	writeTestFile(t, dir, ".env", "LEVEL=warn\nDEBUG=false")
+	_, err = w.Reload()
+	require.Nil(t, err)
+	require.Equal(t, [][2]int{{10, 20}, {20, 5}}, rates)
+
+	changes, err = w.Reload()
+	require.Nil(t, err)
+	require.Nil(t, changes)
+	require.Len(t, all, 2)
+}
+
+func Test_EnvWatcher_Reload_rejects_invalid_values(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestEnvWatcher(t, dir, ".env", "LEVEL=info\nRATE=10")
+	called := false
+	w.Subscribe(func([]EnvChange) { called = true })
+	SubscribeEnv(w, "RATE", 0, func(_, _ uint8) { called = true })
+	w.Validate = func(e *Env) error {
+		c := e.NewChecker()
+		c.RequireNE("LEVEL")
+		return c.Err()
+	}
+	tests := []struct {
+		name    string
+		content string
+		err     error
+	}{
+		{"validator", "LEVEL=\nRATE=10", ErrEnvEmpty},
+		{"parser", "LEVEL=info\nRATE=1000", strconv.ErrRange},
+		{"syntax", "LEVEL=\"info", nil},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				writeTestFile(t, dir, ".env", tt.content)
+				changes, err := w.Reload()
+				require.NotNil(t, err)
+				if nil != tt.err {
+					require.ErrorIs(t, err, tt.err)
+				}
+				require.Nil(t, changes)
+				require.False(t, called)
+				require.Equal(t, "info", NewEnv(w).MustGetEnv("LEVEL"))
+				require.Equal(t, "10", NewEnv(w).MustGetEnv("RATE"))
+			},
+		)
+	}
+	var ee *EnvError
+	writeTestFile(t, dir, ".env", "LEVEL=info\nRATE=x")
+	_, err := w.Reload()
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "RATE", ee.Key)
+	require.Equal(t, "uint8", ee.Type)
+}
+
+func Test_EnvWatcher_Watch_interval(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestEnvWatcher(t, dir, "config.json", `{"rate": 10}`)
+	w.Interval = 10 * time.Millisecond
+	w.Signals = nil
+	rates := make(chan int, 1)
+	SubscribeEnv(w, "RATE", 0, func(_, new int) { rates <- new })
+	errs := make(chan error, 10)
+	w.OnError = func(err error) { errs <- err }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Watch(ctx)
+		close(done)
+	}()
+	writeTestFile(t, dir, "config.json", `{"rate": 20}`)
+	select {
+	case rate := <-rates:
+		require.Equal(t, 20, rate)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no reload")
+	}
+	writeTestFile(t, dir, "config.json", `{"rate": `)
+	select {
+	case err := <-errs:
+		require.NotNil(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no error")
+	}
+	cancel()
+	<-done
+	require.Equal(t, "20", NewEnv(w).MustGetEnv("RATE"))
+}
+
+func Test_EnvWatcher_Watch_signal(t *testing.T) {
+	if "windows" == runtime.GOOS {
+		t.Skip("signals cannot be sent on windows")
+	}
+	dir := t.TempDir()
+	// keeps the test process alive if the signal arrives before Watch()
+	// registers its handler
+	caught := make(chan os.Signal, 10)
+	signal.Notify(caught, syscall.SIGHUP)
+	defer signal.Stop(caught)
+	w := newTestEnvWatcher(t, dir, ".env", "LEVEL=info")
+	levels := make(chan string, 10)
+	SubscribeEnv(w, "LEVEL", "", func(_, new string) { levels <- new })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Watch(ctx)
+	writeTestFile(t, dir, ".env", "LEVEL=debug")
+	p, err := os.FindProcess(os.Getpid())
+	require.Nil(t, err)
+	timeout := time.After(5 * time.Second)
+	for {
+		require.Nil(t, p.Signal(syscall.SIGHUP))
+		select {
+		case level := <-levels:
+			require.Equal(t, "debug", level)
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			require.Fail(t, "no reload")
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LogLevelName is the variable holding the minimum level of loggers, see
+// `GetLogLevel()`.
+const LogLevelName = "LOG_LEVEL"
+
+var ErrUnknownLevel = errors.New("unknown log level")
+
+// Level is the severity of log messages. Loggers discard messages below their
+// threshold. The zero value is `LevelInfo`.
+type Level int8
+
+const (
+	LevelTrace Level = iota - 2
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelPanic
+	LevelFatal
+)
+
+var levelNames = map[Level]string{
+	LevelTrace: "TRACE",
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+	LevelPanic: "PANIC",
+	LevelFatal: "FATAL",
+}
+
+// ParseLevel parses level names case-insensitively, such as `debug` or `WARN`.
+// `warning` and `err` are accepted as aliases of `warn` and `error`.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	switch name {
+	case "WARNING":
+		return LevelWarn, nil
+	case "ERR":
+		return LevelError, nil
+	}
+	for level, n := range levelNames {
+		if n == name {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownLevel, s)
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return "Level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// MarshalText implements `encoding.TextMarshaler`.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler` using `ParseLevel()`,
+// so levels can be read by `GetEnvAs()` and decoded from JSON.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// GetLogLevel returns the level in the `LOG_LEVEL` variable of `DefaultEnv`,
+// or the default value if the variable is not found or empty.
+func GetLogLevel(defaultValue Level) (Level, error) {
+	return DefaultEnv.GetLogLevel(defaultValue)
+}
+
+// GetLogLevel returns the level in the `LOG_LEVEL` variable, or the default
+// value if the variable is not found or empty. The variable is declared in
+// the `Env`.
+func (e *Env) GetLogLevel(defaultValue Level) (Level, error) {
+	e.Declare(
+		EnvVar{
+			Name: LogLevelName, Type: "string", Default: defaultValue.String(),
+			Description: "Minimum level of logged messages: trace, debug, " +
+				"info, warn, error, panic or fatal",
+		},
+	)
+	return GetEnvAsFrom(e, LogLevelName, defaultValue)
+}
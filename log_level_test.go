@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseLevel(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Level
+	}{
+		{"trace", LevelTrace},
+		{"DEBUG", LevelDebug},
+		{" Info ", LevelInfo},
+		{"warn", LevelWarn},
+		{"warning", LevelWarn},
+		{"error", LevelError},
+		{"err", LevelError},
+		{"panic", LevelPanic},
+		{"fatal", LevelFatal},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.s, func(t *testing.T) {
+				level, err := ParseLevel(tt.s)
+				require.Nil(t, err)
+				require.Equal(t, tt.want, level)
+			},
+		)
+	}
+	_, err := ParseLevel("verbose")
+	require.ErrorIs(t, err, ErrUnknownLevel)
+	require.EqualError(t, err, "unknown log level: verbose")
+}
+
+func Test_Level_String(t *testing.T) {
+	require.Equal(t, "TRACE", LevelTrace.String())
+	require.Equal(t, "INFO", Level(0).String())
+	require.Equal(t, "FATAL", LevelFatal.String())
+	require.Equal(t, "Level(9)", Level(9).String())
+	require.True(t, LevelTrace < LevelDebug && LevelPanic < LevelFatal)
+}
+
+func Test_Level_text(t *testing.T) {
+	var v struct{ Level Level }
+	require.Nil(t, Jsoniter.UnmarshalFromString(`{"Level":"warn"}`, &v))
+	require.Equal(t, LevelWarn, v.Level)
+	s, err := Jsoniter.MarshalToString(v)
+	require.Nil(t, err)
+	require.Equal(t, `{"Level":"WARN"}`, s)
+	require.NotNil(t, Jsoniter.UnmarshalFromString(`{"Level":"x"}`, &v))
+	require.Equal(t, LevelWarn, v.Level)
+}
+
+func Test_Env_GetLogLevel(t *testing.T) {
+	env := NewEnv(MapEnv{"APP_LOG_LEVEL": "debug", "BAD_LOG_LEVEL": "x"})
+	level, err := env.Scope("APP_").GetLogLevel(LevelInfo)
+	require.Nil(t, err)
+	require.Equal(t, LevelDebug, level)
+	level, err = env.GetLogLevel(LevelWarn)
+	require.Nil(t, err)
+	require.Equal(t, LevelWarn, level)
+	v, ok := env.Declared.Get(LogLevelName)
+	require.True(t, ok)
+	require.Equal(t, "WARN", v.Default)
+	_, err = env.Scope("BAD_").GetLogLevel(LevelInfo)
+	var ee *EnvError
+	require.ErrorAs(t, err, &ee)
+	require.Equal(t, "BAD_LOG_LEVEL", ee.Key)
+	require.ErrorIs(t, err, ErrUnknownLevel)
+	level, err = GetEnvAsFrom(env.Scope("APP_"), LogLevelName, LevelInfo)
+	require.Nil(t, err)
+	require.Equal(t, LevelDebug, level)
+}
+
+func Test_GetLogLevel(t *testing.T) {
+	t.Setenv(LogLevelName, "error")
+	level, err := GetLogLevel(LevelInfo)
+	require.Nil(t, err)
+	require.Equal(t, LevelError, level)
+}
@@ -3,20 +3,31 @@ package utils
 import (
 	"fmt"
 	lg "log"
+	"os"
 	"strings"
 )
 
+// for unit test mocking
+var osExit = os.Exit
+
 type TaggedLogger interface {
+	Tracef(format string, args ...interface{})
 	Debugf(format string, args ...interface{})
-	Errorf(format string, args ...interface{})
 	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
 	Panicf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
 	PanicIfError(err error)
+	// Enabled reports whether messages of the level are logged, so expensive
+	// arguments can be skipped.
+	Enabled(level Level) bool
 }
 
 type SimpleTaggedLog struct {
 	logger *lg.Logger
-	Debug  bool
+	// Messages below this level are discarded
+	Level Level
 }
 
 func NewLogger() SimpleTaggedLog {
@@ -27,63 +38,139 @@ func NewDebugLogger() SimpleTaggedLog {
 	return WrapLogger(lg.Default(), true)
 }
 
-func WrapLogger(logger *lg.Logger, debug bool) SimpleTaggedLog {
-	return SimpleTaggedLog{logger: logger, Debug: debug}
+// NewEnvLogger returns a logger of the level in the `LOG_LEVEL` variable,
+// `LevelInfo` by default.
+func NewEnvLogger() (SimpleTaggedLog, error) {
+	level, err := GetLogLevel(LevelInfo)
+	if err != nil {
+		return SimpleTaggedLog{}, err
+	}
+	return WrapLoggerLevel(lg.Default(), level), nil
 }
 
-func (l SimpleTaggedLog) Debugf(format string, args ...interface{}) {
-	if l.Debug {
-		l.logger.Printf("[DEBUG] "+format, args...)
+// WrapLogger wraps the logger with level `LevelDebug` if `debug` is `true`,
+// otherwise `LevelInfo`.
+func WrapLogger(logger *lg.Logger, debug bool) SimpleTaggedLog {
+	if debug {
+		return WrapLoggerLevel(logger, LevelDebug)
 	}
+	return WrapLoggerLevel(logger, LevelInfo)
 }
 
-func (l SimpleTaggedLog) Errorf(format string, args ...interface{}) {
-	l.logger.Printf("[ERROR] "+format, args...)
+func WrapLoggerLevel(logger *lg.Logger, level Level) SimpleTaggedLog {
+	return SimpleTaggedLog{logger: logger, Level: level}
+}
+
+func (l SimpleTaggedLog) Enabled(level Level) bool {
+	return level >= l.Level
+}
+
+func (l SimpleTaggedLog) Tracef(format string, args ...interface{}) {
+	l.logf(LevelTrace, format, args...)
+}
+
+func (l SimpleTaggedLog) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, format, args...)
 }
 
 func (l SimpleTaggedLog) Infof(format string, args ...interface{}) {
-	l.logger.Printf("[INFO] "+format, args...)
+	l.logf(LevelInfo, format, args...)
+}
+
+func (l SimpleTaggedLog) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, format, args...)
 }
 
+func (l SimpleTaggedLog) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, format, args...)
+}
+
+// Panicf panics even if `LevelPanic` is not enabled.
 func (l SimpleTaggedLog) Panicf(format string, args ...interface{}) {
-	l.logger.Panicf("[PANIC] "+format, args...)
+	s := fmt.Sprintf("[PANIC] "+format, args...)
+	if l.Enabled(LevelPanic) {
+		_ = l.logger.Output(2, s)
+	}
+	panic(s)
+}
+
+// Fatalf exits the process even if `LevelFatal` is not enabled.
+func (l SimpleTaggedLog) Fatalf(format string, args ...interface{}) {
+	l.logf(LevelFatal, format, args...)
+	osExit(1)
 }
 
 func (l SimpleTaggedLog) PanicIfError(err error) {
 	if err != nil {
-		l.logger.Panicf("[PANIC] " + err.Error())
+		l.Panicf("%s", err.Error())
+	}
+}
+
+func (l SimpleTaggedLog) logf(level Level, format string, args ...interface{}) {
+	if l.Enabled(level) {
+		_ = l.logger.Output(3, fmt.Sprintf(tagLog(level, format), args...))
 	}
 }
 
+// StringTaggedLogger records messages in memory, mostly for tests. It must be
+// created by `NewStringTaggedLogger()`, which records all levels, unlike the
+// zero `Level` which is `LevelInfo`. `Fatalf()` panics instead of exiting, so
+// tests can recover.
 type StringTaggedLogger struct {
 	sb *strings.Builder
+	// Messages below this level are discarded, set to `LevelTrace` by
+	// `NewStringTaggedLogger()`
+	Level Level
 }
 
+// NewStringTaggedLogger returns a logger of all levels.
 func NewStringTaggedLogger() StringTaggedLogger {
-	return StringTaggedLogger{sb: &strings.Builder{}}
+	return StringTaggedLogger{sb: &strings.Builder{}, Level: LevelTrace}
 }
 
-func (m StringTaggedLogger) Debugf(format string, args ...interface{}) {
-	m.sb.WriteString(fmt.Sprintf("[DEBUG] "+format+"\n", args...))
+func (m StringTaggedLogger) Enabled(level Level) bool {
+	return level >= m.Level
 }
 
-func (m StringTaggedLogger) Errorf(format string, args ...interface{}) {
-	m.sb.WriteString(fmt.Sprintf("[ERROR] "+format+"\n", args...))
+func (m StringTaggedLogger) Tracef(format string, args ...interface{}) {
+	m.logf(LevelTrace, format, args...)
+}
+
+func (m StringTaggedLogger) Debugf(format string, args ...interface{}) {
+	m.logf(LevelDebug, format, args...)
 }
 
 func (m StringTaggedLogger) Infof(format string, args ...interface{}) {
-	m.sb.WriteString(fmt.Sprintf("[INFO] "+format+"\n", args...))
+	m.logf(LevelInfo, format, args...)
+}
+
+func (m StringTaggedLogger) Warnf(format string, args ...interface{}) {
+	m.logf(LevelWarn, format, args...)
 }
 
+func (m StringTaggedLogger) Errorf(format string, args ...interface{}) {
+	m.logf(LevelError, format, args...)
+}
+
+// Panicf panics even if `LevelPanic` is not enabled.
 func (m StringTaggedLogger) Panicf(format string, args ...interface{}) {
-	s := fmt.Sprintf("[PANIC] "+format+"\n", args...)
-	m.sb.WriteString(s)
+	s := fmt.Sprintf(tagLog(LevelPanic, format)+"\n", args...)
+	if m.Enabled(LevelPanic) {
+		m.sb.WriteString(s)
+	}
 	panic(s)
 }
 
+// Fatalf panics even if `LevelFatal` is not enabled, rather than exiting the
+// process.
+func (m StringTaggedLogger) Fatalf(format string, args ...interface{}) {
+	m.logf(LevelFatal, format, args...)
+	panic(fmt.Sprintf(tagLog(LevelFatal, format)+"\n", args...))
+}
+
 func (m StringTaggedLogger) PanicIfError(err error) {
 	if err != nil {
-		m.Panicf(err.Error())
+		m.Panicf("%s", err.Error())
 	}
 }
 
@@ -91,5 +178,17 @@ func (m StringTaggedLogger) String() string {
 	return m.sb.String()
 }
 
+func (m StringTaggedLogger) logf(
+	level Level, format string, args ...interface{},
+) {
+	if m.Enabled(level) {
+		m.sb.WriteString(fmt.Sprintf(tagLog(level, format)+"\n", args...))
+	}
+}
+
+func tagLog(level Level, format string) string {
+	return "[" + level.String() + "] " + format
+}
+
 var _ TaggedLogger = SimpleTaggedLog{}
 var _ TaggedLogger = StringTaggedLogger{}
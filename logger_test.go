@@ -19,13 +19,13 @@ func setupLoggerTest() (*SimpleTaggedLog, *bytes.Buffer) {
 func Test_NewLogger(t *testing.T) {
 	logger := NewLogger()
 	require.Equal(t, log.Default(), logger.logger)
-	require.False(t, logger.Debug)
+	require.Equal(t, LevelInfo, logger.Level)
 }
 
 func Test_NewDebugLogger(t *testing.T) {
 	logger := NewDebugLogger()
 	require.Equal(t, log.Default(), logger.logger)
-	require.True(t, logger.Debug)
+	require.Equal(t, LevelDebug, logger.Level)
 }
 
 func Test_Debugf(t *testing.T) {
@@ -92,3 +92,81 @@ func Test_StringTaggedLogger_does_not_panic(t *testing.T) {
 	require.NotPanics(t, func() { logger.PanicIfError(nil) })
 	require.Empty(t, logger.String())
 }
+
+func Test_NewEnvLogger(t *testing.T) {
+	t.Setenv(LogLevelName, "warning")
+	logger, err := NewEnvLogger()
+	require.Nil(t, err)
+	require.Equal(t, log.Default(), logger.logger)
+	require.Equal(t, LevelWarn, logger.Level)
+	t.Setenv(LogLevelName, "verbose")
+	_, err = NewEnvLogger()
+	require.ErrorIs(t, err, ErrUnknownLevel)
+}
+
+func Test_SimpleTaggedLog_levels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := WrapLoggerLevel(log.New(io.Writer(&buf), "", 0), LevelTrace)
+	logger.Tracef("test %d", 1)
+	logger.Warnf("test %d", 2)
+	require.Equal(t, "[TRACE] test 1\n[WARN] test 2\n", buf.String())
+}
+
+func Test_SimpleTaggedLog_threshold(t *testing.T) {
+	var buf bytes.Buffer
+	logger := WrapLoggerLevel(log.New(io.Writer(&buf), "", 0), LevelWarn)
+	require.False(t, logger.Enabled(LevelInfo))
+	require.True(t, logger.Enabled(LevelWarn))
+	require.True(t, logger.Enabled(LevelFatal))
+	logger.Tracef("trace")
+	logger.Debugf("debug")
+	logger.Infof("info")
+	logger.Warnf("warn")
+	logger.Errorf("error")
+	require.Equal(t, "[WARN] warn\n[ERROR] error\n", buf.String())
+	buf.Reset()
+	logger.Level = LevelFatal + 1
+	require.Panics(t, func() { logger.Panicf("panic") })
+	require.Empty(t, buf.String())
+}
+
+func Test_SimpleTaggedLog_Fatalf(t *testing.T) {
+	tmp := osExit
+	defer func() { osExit = tmp }()
+	code := -1
+	osExit = func(c int) { code = c }
+	logger, buf := setupLoggerTest()
+	logger.Fatalf("test %d", 1)
+	require.Equal(t, "[FATAL] test 1\n", buf.String())
+	require.Equal(t, 1, code)
+}
+
+func Test_StringTaggedLogger_levels(t *testing.T) {
+	tmp := osExit
+	defer func() { osExit = tmp }()
+	osExit = func(int) { t.Fatal("osExit must not be called") }
+	logger := NewStringTaggedLogger()
+	require.True(t, logger.Enabled(LevelTrace))
+	logger.Tracef("test %d", 1)
+	logger.Warnf("test %d", 2)
+	require.PanicsWithValue(
+		t, "[FATAL] test 3\n", func() { logger.Fatalf("test %d", 3) },
+	)
+	require.Equal(
+		t, "[TRACE] test 1\n[WARN] test 2\n[FATAL] test 3\n", logger.String(),
+	)
+}
+
+func Test_StringTaggedLogger_threshold(t *testing.T) {
+	logger := NewStringTaggedLogger()
+	logger.Level = LevelError
+	require.False(t, logger.Enabled(LevelWarn))
+	logger.Debugf("debug")
+	logger.Infof("info")
+	logger.Warnf("warn")
+	logger.Errorf("error %s", "%d")
+	require.Equal(t, "[ERROR] error %d\n", logger.String())
+	logger.Level = LevelFatal
+	require.Panics(t, func() { logger.PanicIfError(assert.AnError) })
+	require.Equal(t, "[ERROR] error %d\n", logger.String())
+}
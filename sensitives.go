@@ -6,7 +6,6 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/argon2"
@@ -20,6 +19,31 @@ const (
 	PasswordHashSaltLenName = "PASSWORD_HASH_SALT_LENGTH"
 )
 
+func init() {
+	DeclareEnv(
+		EnvVar{
+			Name: PasswordHashTimesName, Type: "uint32", Default: "1",
+			Description: "Number of iterations of password hashing",
+		},
+		EnvVar{
+			Name: PasswordHashMemoryName, Type: "uint32", Default: "65536",
+			Description: "Amount of memory used by password hashing, in KB",
+		},
+		EnvVar{
+			Name: PasswordHashThreadsName, Type: "uint8", Default: "4",
+			Description: "Number of threads used by password hashing",
+		},
+		EnvVar{
+			Name: PasswordHashKeyLenName, Type: "uint32", Default: "32",
+			Description: "Length of password hashes, in bytes",
+		},
+		EnvVar{
+			Name: PasswordHashSaltLenName, Type: "uint32", Default: "16",
+			Description: "Length of password hash salts, in bytes",
+		},
+	)
+}
+
 var (
 	// for unit test mocking
 	randomBytes = rand.Read
@@ -46,35 +70,30 @@ type PasswordHashParams struct {
 }
 
 func DefaultPasswordHashParams() (*PasswordHashParams, error) {
-	s := GetEnvWithDefault(PasswordHashTimesName, "1")
-	times, err := strconv.ParseUint(s, 10, 32)
+	times, err := GetEnvUint(PasswordHashTimesName, 1, 32)
 	if err != nil {
 		return nil, err
 	}
-	s = GetEnvWithDefault(PasswordHashMemoryName, "65536")
-	memory, err := strconv.ParseUint(s, 10, 32)
+	memory, err := GetEnvUint(PasswordHashMemoryName, 65536, 32)
 	if err != nil {
 		return nil, err
 	}
-	s = GetEnvWithDefault(PasswordHashThreadsName, "4")
-	threads, err := strconv.ParseUint(s, 10, 32)
+	threads, err := GetEnvUint8(PasswordHashThreadsName, 4)
 	if err != nil {
 		return nil, err
 	}
-	s = GetEnvWithDefault(PasswordHashKeyLenName, "32")
-	keyLen, err := strconv.ParseUint(s, 10, 32)
+	keyLen, err := GetEnvUint(PasswordHashKeyLenName, 32, 32)
 	if err != nil {
 		return nil, err
 	}
-	s = GetEnvWithDefault(PasswordHashSaltLenName, "16")
-	saltLen, err := strconv.ParseUint(s, 10, 32)
+	saltLen, err := GetEnvUint(PasswordHashSaltLenName, 16, 32)
 	if err != nil {
 		return nil, err
 	}
 	return &PasswordHashParams{
 		Times:   uint32(times),
 		Memory:  uint32(memory),
-		Threads: uint8(threads),
+		Threads: threads,
 		KeyLen:  uint32(keyLen),
 		SaltLen: uint32(saltLen),
 	}, nil
@@ -3,6 +3,8 @@ package utils
 import (
 	"errors"
 	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -43,6 +45,21 @@ func Test_password_hash_and_compare(t *testing.T) {
 	require.True(t, eq)
 }
 
+func Test_DefaultPasswordHashParams_reads_secret_files(t *testing.T) {
+	defer resetPasswordHashParams(t)
+	DefaultEnv.FileSecrets = true
+	defer func() { DefaultEnv.FileSecrets = false }()
+	file := filepath.Join(t.TempDir(), "times")
+	require.Nil(t, os.WriteFile(file, []byte("3\n"), 0600))
+	t.Setenv(PasswordHashTimesName+EnvFileSuffix, file)
+	params, err := DefaultPasswordHashParams()
+	require.Nil(t, err)
+	require.Equal(t, uint32(3), params.Times)
+	require.Nil(t, os.Setenv(PasswordHashTimesName, "2"))
+	_, err = DefaultPasswordHashParams()
+	require.ErrorIs(t, err, ErrEnvFileConflict)
+}
+
 func Test_password_hash_and_compare_returns_false_if_not_equal(t *testing.T) {
 	defer resetPasswordHashParams(t)
 	require.Nil(t, os.Setenv(PasswordHashTimesName, "2"))
@@ -78,6 +95,13 @@ func Test_hash_threads_returns_error_if_invalid_value(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func Test_hash_threads_returns_error_if_out_of_range(t *testing.T) {
+	defer resetPasswordHashParams(t)
+	require.Nil(t, os.Setenv(PasswordHashThreadsName, "256"))
+	_, err := DefaultPasswordHashParams()
+	require.ErrorIs(t, err, strconv.ErrRange)
+}
+
 func Test_hash_key_len_returns_error_if_invalid_value(t *testing.T) {
 	defer resetPasswordHashParams(t)
 	require.Nil(t, os.Setenv(PasswordHashKeyLenName, "invalid"))
@@ -45,3 +45,33 @@ func StringIndexOfAny(s string, subs []string) int {
 func StringContainsAny(s string, subs []string) bool {
 	return StringIndexOfAny(s, subs) > -1
 }
+
+// EditDistance returns the optimal string alignment distance between the two
+// strings, the minimum number of single character insertions, deletions,
+// substitutions and transpositions of adjacent characters to change one into
+// the other.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// rows of the distance matrix for the previous two, and current runes of a
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
@@ -62,3 +62,16 @@ func Test_StringContainsAny_finds_first_substring(t *testing.T) {
 func Test_StringContainsAny_finds_second_substring(t *testing.T) {
 	require.True(t, StringContainsAny("task", []string{"e", "s"}))
 }
+
+func Test_EditDistance(t *testing.T) {
+	require.Equal(t, 0, EditDistance("", ""))
+	require.Equal(t, 3, EditDistance("abc", ""))
+	require.Equal(t, 3, EditDistance("", "abc"))
+	require.Equal(t, 0, EditDistance("abc", "abc"))
+	require.Equal(t, 1, EditDistance("THREDS", "THREADS"))
+	require.Equal(t, 1, EditDistance("ab", "ba"))
+	require.Equal(t, 1, EditDistance("HOTS", "HOST"))
+	require.Equal(t, 3, EditDistance("abc", "ca"))
+	require.Equal(t, 3, EditDistance("kitten", "sitting"))
+	require.Equal(t, 1, EditDistance("héllo", "hello"))
+}